@@ -103,6 +103,12 @@ func getCommandConfig(cmd *cobra.Command, args []string) (*config.Config, error)
 	if namespace, exists := getNamespaceFlag(cmd.Flags()); exists {
 		cfg.Namespace = namespace
 	}
+	if createNamespace, _ := cmd.Flags().GetBool(createNamespaceFlagName); createNamespace || cfg.EphemeralNamespace != nil {
+		err = cfg.SetEphemeralNamespace()
+		if err != nil {
+			return nil, err
+		}
+	}
 	if len(args) == 0 {
 		for _, service := range cfg.Services {
 			cfg.AddToFilter(service)
@@ -119,3 +125,18 @@ func getCommandConfig(cmd *cobra.Command, args []string) (*config.Config, error)
 	}
 	return cfg, nil
 }
+
+// getNamespaceCommandConfig returns the configuration of commands that operate on all environments in a namespace. Unlike
+// getCommandConfig, this does not load a docker compose file.
+func getNamespaceCommandConfig(cmd *cobra.Command) (*config.Config, error) {
+	cfg := &config.Config{
+		EnvironmentLabel: "env",
+	}
+	if err := setFromKubeConfig(cfg); err != nil {
+		return nil, err
+	}
+	if namespace, exists := getNamespaceFlag(cmd.Flags()); exists {
+		cfg.Namespace = namespace
+	}
+	return cfg, nil
+}
@@ -16,6 +16,14 @@ func newDownCli() *cobra.Command {
 		Long: "destroy all pods and services",
 		RunE: downCommand,
 	}
+	downCmd.PersistentFlags().Int64("grace-period", -1, "The duration in seconds given to pods to terminate gracefully. "+
+		"Negative means the default of each pod, and zero deletes pods immediately")
+	downCmd.PersistentFlags().String("rmi", "", "Remove images created by up. One of local (remove local tags and volume init images) "+
+		"and all (also delete pushed images from the docker registry, if permitted)")
+	downCmd.PersistentFlags().Bool("remove-orphans", false, "Delete the resources of services that are not in the docker compose file")
+	downCmd.PersistentFlags().Duration("timeout", 0, "The maximum duration to wait for resources to be deleted (e.g. 5m), when --wait is "+
+		"set. Zero means no timeout")
+	downCmd.PersistentFlags().Bool("wait", false, "Wait until deleted resources no longer exist")
 	return downCmd
 }
 
@@ -24,7 +32,19 @@ func downCommand(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
-	err = down.Run(cfg)
+	opts := &down.Options{}
+	opts.RemoveImages, _ = cmd.Flags().GetString("rmi")
+	err = down.ValidateRemoveImages(opts.RemoveImages)
+	if err != nil {
+		return err
+	}
+	if gracePeriod, _ := cmd.Flags().GetInt64("grace-period"); gracePeriod >= 0 {
+		opts.GracePeriodSeconds = &gracePeriod
+	}
+	opts.RemoveOrphans, _ = cmd.Flags().GetBool("remove-orphans")
+	opts.Timeout, _ = cmd.Flags().GetDuration("timeout")
+	opts.Wait, _ = cmd.Flags().GetBool("wait")
+	err = down.Run(cfg, opts)
 	if err != nil {
 		log.Error(err)
 		os.Exit(1)
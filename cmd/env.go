@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/env"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+func newEnvCli() *cobra.Command {
+	var envCmd = &cobra.Command{
+		Use:   "env",
+		Short: "Manage environments",
+	}
+	var lsCmd = &cobra.Command{
+		Use:   "ls",
+		Short: "List the environments in the namespace",
+		Long: "lists the environments in the namespace by grouping pods and services by the environment label. Does not require a " +
+			"docker compose file.",
+		RunE: envLsCommand,
+	}
+	lsCmd.PersistentFlags().StringP("output", "o", "", "Output format. One of table (the default) and json")
+	envCmd.AddCommand(lsCmd)
+	return envCmd
+}
+
+func formatEnvironmentList(environments []*env.Environment, now time.Time) string {
+	rows := [][]string{
+		{"ENV-ID", "SERVICES", "READY", "AGE", "CREATED-BY"},
+	}
+	for _, e := range environments {
+		createdBy := e.CreatedBy
+		if createdBy == "" {
+			createdBy = "<unknown>"
+		}
+		rows = append(rows, []string{
+			e.ID,
+			strconv.Itoa(e.Services),
+			fmt.Sprintf("%d/%d", e.Ready, e.Services),
+			now.Sub(e.CreatedAt).Truncate(time.Second).String(),
+			createdBy,
+		})
+	}
+	return util.FormatTable(rows)
+}
+
+func envLsCommand(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("no positional arguments are allowed")
+	}
+	output, _ := cmd.Flags().GetString("output")
+	if output != "" && output != "table" && output != "json" {
+		return fmt.Errorf("invalid output format %#v, must be one of \"table\" and \"json\"", output)
+	}
+	cfg, err := getNamespaceCommandConfig(cmd)
+	if err != nil {
+		return err
+	}
+	environments, err := env.List(cfg)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	if output == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(environments)
+	}
+	fmt.Println(formatEnvironmentList(environments, time.Now()))
+	return nil
+}
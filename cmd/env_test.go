@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/env"
+	"github.com/spf13/cobra"
+)
+
+func TestEnvLsCommand_ArgsError(t *testing.T) {
+	cmd := &cobra.Command{}
+	err := envLsCommand(cmd, []string{"env1"})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestEnvLsCommand_OutputError(t *testing.T) {
+	cmd := newEnvCli().Commands()[0]
+	_ = cmd.Flags().Set("output", "yaml")
+	err := envLsCommand(cmd, []string{})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestFormatEnvironmentList(t *testing.T) {
+	environments := []*env.Environment{
+		{
+			ID:        "env1",
+			Services:  2,
+			Ready:     1,
+			CreatedAt: time.Unix(0, 0),
+		},
+	}
+	output := formatEnvironmentList(environments, time.Unix(60, 0))
+	expected := "ENV-ID  SERVICES  READY  AGE   CREATED-BY\nenv1    2         1/2    1m0s  <unknown>\n"
+	if output != expected {
+		t.Errorf("%q", output)
+	}
+}
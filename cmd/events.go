@@ -0,0 +1,38 @@
+package cmd
+
+import (
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/events"
+	"github.com/spf13/cobra"
+)
+
+func newEventsCli() *cobra.Command {
+	var eventsCmd = &cobra.Command{
+		Use:   "events [services...]",
+		Short: "Stream lifecycle events of services",
+		Long: "watches pods, services and Kubernetes events of the environment, and writes lifecycle events of the specified docker " +
+			"compose services (or of all docker compose services) such as created, image-pulled, started, ready, exited and deleted",
+		RunE: eventsCommand,
+	}
+	eventsCmd.PersistentFlags().Bool("json", false, "Write events as JSON objects, one per line")
+	return eventsCmd
+}
+
+func eventsCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	opts := &events.Options{
+		Out: os.Stdout,
+	}
+	opts.JSON, _ = cmd.Flags().GetBool("json")
+	err = events.Run(cfg, opts)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/exec"
+	"github.com/spf13/cobra"
+)
+
+func newExecCli() *cobra.Command {
+	var execCmd = &cobra.Command{
+		Use:   "exec [flags] <service> <command> [args...]",
+		Short: "Execute a command in a running container",
+		Long:  "runs a command in a running pod of a docker compose service, similar to docker-compose exec",
+		Args:  cobra.MinimumNArgs(2),
+		RunE:  execCommand,
+	}
+	// Flags after the service belong to the command.
+	execCmd.Flags().SetInterspersed(false)
+	execCmd.Flags().BoolP("no-tty", "T", false, "Disable pseudo-tty allocation. By default a TTY is allocated if stdin is a terminal")
+	execCmd.Flags().StringP("user", "u", "", "Run the command as this user (requires su in the container and the container to run as root)")
+	return execCmd
+}
+
+func execCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := getCommandConfig(cmd, args[:1])
+	if err != nil {
+		return err
+	}
+	composeService := cfg.Services[args[0]]
+	noTTY, _ := cmd.Flags().GetBool("no-tty")
+	opts := &exec.Options{
+		Command: args[1:],
+		Stderr:  os.Stderr,
+		Stdin:   os.Stdin,
+		Stdout:  os.Stdout,
+		TTY:     !noTTY,
+	}
+	opts.User, _ = cmd.Flags().GetString("user")
+	exitCode, err := exec.Run(cfg, composeService, opts)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/gc"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+func newGcCli() *cobra.Command {
+	var gcCmd = &cobra.Command{
+		Use:   "gc",
+		Short: "Deletes stale environments",
+		Long: "finds environments in the namespace whose resources are all older than --older-than or have an expired --ttl, lists them " +
+			"and deletes all their resources. Does not require a docker compose file.",
+		RunE: gcCommand,
+	}
+	gcCmd.PersistentFlags().Duration("older-than", 0, "Environments whose resources were all created longer than this duration ago are "+
+		"stale (e.g. 24h). If not set, only environments with an expired --ttl are stale")
+	gcCmd.PersistentFlags().Bool("dry-run", false, "Only list the stale environments, do not delete them")
+	return gcCmd
+}
+
+func formatEnvironments(environments []*gc.Environment, now time.Time) string {
+	rows := [][]string{
+		{"ENV-ID", "AGE", "RESOURCES"},
+	}
+	for _, env := range environments {
+		rows = append(rows, []string{
+			env.ID,
+			now.Sub(env.CreatedAt).Truncate(time.Second).String(),
+			strconv.Itoa(len(env.Resources)),
+		})
+	}
+	return util.FormatTable(rows)
+}
+
+func gcCommand(cmd *cobra.Command, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("no positional arguments are allowed")
+	}
+	cfg, err := getNamespaceCommandConfig(cmd)
+	if err != nil {
+		return err
+	}
+	opts := &gc.Options{}
+	opts.OlderThan, _ = cmd.Flags().GetDuration("older-than")
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	environments, err := gc.Find(cfg, opts)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	if len(environments) == 0 {
+		fmt.Println("no stale environments found")
+		return nil
+	}
+	fmt.Println(formatEnvironments(environments, time.Now()))
+	if dryRun {
+		return nil
+	}
+	err = gc.Delete(cfg, environments)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/gc"
+	"github.com/spf13/cobra"
+)
+
+func TestGcCommand_ArgsError(t *testing.T) {
+	cmd := &cobra.Command{}
+	err := gcCommand(cmd, []string{"service1"})
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestFormatEnvironments(t *testing.T) {
+	now := time.Unix(7200, 0)
+	environments := []*gc.Environment{
+		{
+			ID:        "env1",
+			CreatedAt: time.Unix(0, 0),
+			Resources: []gc.Resource{
+				{Kind: "Pod", Name: "a-env1"},
+			},
+		},
+	}
+	output := formatEnvironments(environments, now)
+	expected := "ENV-ID  AGE     RESOURCES\nenv1    2h0m0s  1\n"
+	if output != expected {
+		t.Errorf("%q", output)
+	}
+}
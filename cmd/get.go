@@ -1,66 +1,154 @@
 package cmd
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
-
+	"regexp"
+	"strconv"
+	"strings"
 	"text/template"
 
 	log "github.com/Sirupsen/logrus"
 	details "github.com/kube-compose/kube-compose/internal/app/get"
 	"github.com/kube-compose/kube-compose/internal/pkg/util"
 	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
 )
 
+const goTemplateOutputPrefix = "go-template="
+
 func newGetCli() *cobra.Command {
 	var getCmd = &cobra.Command{
-		Use:   "get",
-		Short: "Show details of a specific resource",
-		Long:  "Print a detailed description of the selected resources, including related resources such as hostname or host IP.",
-		RunE:  getCommand,
+		Use:   "get [services...]",
+		Short: "Show details of services",
+		Long: "Print a detailed description of the selected docker compose services (or of all docker compose services), including related " +
+			"resources such as hostname or host IP.",
+		RunE: getCommand,
 	}
-	getCmd.PersistentFlags().StringP("output", "o", "", "Go template string")
+	getCmd.PersistentFlags().StringP("output", "o", "table", "Output format. One of table, json, yaml or go-template=<template>, where "+
+		"the template is executed for each service")
+	getCmd.PersistentFlags().Bool("dotenv", false, "Print <SERVICE>_HOSTNAME, <SERVICE>_CLUSTER_IP and <SERVICE>_POD_IP lines that can be "+
+		"sourced by a shell or loaded as a .env file")
 	return getCmd
 }
 
-// TODO: If no service is specified then it should iterate through all services in the docker-compose
-// https://github.com/kube-compose/kube-compose/issues/126
-func getCommand(cmd *cobra.Command, args []string) error {
-	if len(args) != 1 {
-		return fmt.Errorf("exactly one positional argument is required")
+func formatServiceDetailsTable(services []*details.ServiceDetails) string {
+	rows := [][]string{
+		{"NAME", "HOSTNAME", "CLUSTER-IP", "PORTS", "POD", "POD-IP", "READY", "IMAGE", "EXTERNAL-URLS"},
 	}
-	cfg, err := getCommandConfig(cmd, args)
+	for _, d := range services {
+		rows = append(rows, []string{
+			d.Name,
+			d.Hostname,
+			d.ClusterIP,
+			formatList(d.Ports),
+			formatOptional(d.Pod),
+			formatOptional(d.PodIP),
+			strconv.FormatBool(d.Ready),
+			formatOptional(d.Image),
+			formatList(d.ExternalURLs),
+		})
+	}
+	return util.FormatTable(rows)
+}
+
+func formatOptional(s string) string {
+	if s == "" {
+		return "<none>"
+	}
+	return s
+}
+
+func formatList(list []string) string {
+	return formatOptional(strings.Join(list, ","))
+}
+
+var dotenvInvalidCharRegexp = regexp.MustCompile("[^A-Z0-9_]")
+
+// getDotenvPrefix converts the name of a docker compose service to a valid environment variable name prefix (e.g. my-db becomes MY_DB).
+func getDotenvPrefix(name string) string {
+	return dotenvInvalidCharRegexp.ReplaceAllString(strings.ToUpper(name), "_")
+}
+
+func writeServiceDetailsDotenv(w io.Writer, services []*details.ServiceDetails) {
+	for _, d := range services {
+		prefix := getDotenvPrefix(d.Name)
+		fmt.Fprintf(w, "%s_HOSTNAME=%s\n", prefix, d.Hostname)
+		if d.ClusterIP != "" && d.ClusterIP != "None" {
+			fmt.Fprintf(w, "%s_CLUSTER_IP=%s\n", prefix, d.ClusterIP)
+		}
+		if d.PodIP != "" {
+			fmt.Fprintf(w, "%s_POD_IP=%s\n", prefix, d.PodIP)
+		}
+	}
+}
+
+// writeLine writes s to w, ending it with a single newline so that every output format is terminated the same way.
+func writeLine(w io.Writer, s string) error {
+	_, err := io.WriteString(w, strings.TrimRight(s, "\n")+"\n")
+	return err
+}
+
+func writeServiceDetails(w io.Writer, services []*details.ServiceDetails, output string) error {
+	if services == nil {
+		services = []*details.ServiceDetails{}
+	}
+	switch output {
+	case "table":
+		return writeLine(w, formatServiceDetailsTable(services))
+	case "json":
+		data, err := json.MarshalIndent(services, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeLine(w, string(data))
+	case "yaml":
+		data, err := yaml.Marshal(services)
+		if err != nil {
+			return err
+		}
+		return writeLine(w, string(data))
+	}
+	// For backwards compatibility, an output that is not one of the above formats is a Go template, even without the prefix.
+	tmpl, err := template.New("output").Parse(strings.TrimPrefix(output, goTemplateOutputPrefix))
 	if err != nil {
 		return err
 	}
-	var tmpl *template.Template
-	if cmd.Flags().Changed("output") {
-		var output string
-		output, _ = cmd.Flags().GetString("output")
-		tmpl, err = template.New("test").Parse(output)
+	for _, d := range services {
+		var sb strings.Builder
+		err = tmpl.Execute(&sb, d)
 		if err != nil {
-			log.Error(err)
-			os.Exit(1)
+			return err
+		}
+		err = writeLine(w, sb.String())
+		if err != nil {
+			return err
 		}
 	}
-	service := cfg.Services[args[0]]
-	d, err := details.GetServiceDetails(cfg, service)
+	return nil
+}
+
+func getCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	services, err := details.GetServicesDetails(cfg)
 	if err != nil {
 		log.Error(err)
 		os.Exit(1)
 	}
-	if tmpl != nil {
-		err = tmpl.Execute(os.Stdout, d)
-		if err != nil {
-			log.Error(err)
-			os.Exit(1)
-		}
-	} else {
-		output := util.FormatTable([][]string{
-			{"NAME", "HOSTNAME", "CLUSTER-IP"},
-			{d.Name, d.Hostname, d.ClusterIP},
-		})
-		fmt.Println(output)
+	if dotenv, _ := cmd.Flags().GetBool("dotenv"); dotenv {
+		writeServiceDetailsDotenv(cmd.OutOrStdout(), services)
+		return nil
+	}
+	output, _ := cmd.Flags().GetString("output")
+	err = writeServiceDetails(cmd.OutOrStdout(), services, output)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
 	}
 	return nil
 }
@@ -1,12 +1,15 @@
 package cmd
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
+	details "github.com/kube-compose/kube-compose/internal/app/get"
 	"github.com/spf13/cobra"
 )
 
-func TestGetCommand_NoArgsError(t *testing.T) {
+func TestGetCommand_NoArgsConfigError(t *testing.T) {
 	cmd := &cobra.Command{}
 	args := []string{}
 	err := getCommand(cmd, args)
@@ -23,3 +26,122 @@ func TestGetCommand_ConfigError(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func newTestServiceDetails() []*details.ServiceDetails {
+	return []*details.ServiceDetails{
+		{
+			Name:      "my-db",
+			Hostname:  "my-db-env1.ns.svc.cluster.local",
+			ClusterIP: "10.0.0.1",
+			Ports:     []string{"5432/tcp"},
+			Pod:       "my-db-env1",
+			PodIP:     "172.17.0.2",
+			Ready:     true,
+			Image:     "postgres:11",
+		},
+		{
+			Name:      "worker",
+			Hostname:  "worker-env1.ns.svc.cluster.local",
+			ClusterIP: "None",
+		},
+	}
+}
+
+func TestWriteServiceDetails_Table(t *testing.T) {
+	var buffer bytes.Buffer
+	err := writeServiceDetails(&buffer, newTestServiceDetails(), "table")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "NAME    HOSTNAME                          CLUSTER-IP  PORTS     POD         POD-IP      READY  IMAGE        EXTERNAL-URLS\n" +
+		"my-db   my-db-env1.ns.svc.cluster.local   10.0.0.1    5432/tcp  my-db-env1  172.17.0.2  true   postgres:11  <none>\n" +
+		"worker  worker-env1.ns.svc.cluster.local  None        <none>    <none>      <none>      false  <none>       <none>\n"
+	if buffer.String() != expected {
+		t.Errorf("%#v", buffer.String())
+	}
+}
+
+func TestWriteServiceDetails_JSON(t *testing.T) {
+	var buffer bytes.Buffer
+	err := writeServiceDetails(&buffer, nil, "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if buffer.String() != "[]\n" {
+		t.Errorf("%#v", buffer.String())
+	}
+}
+
+func TestWriteServiceDetails_YAML(t *testing.T) {
+	var buffer bytes.Buffer
+	err := writeServiceDetails(&buffer, newTestServiceDetails()[1:], "yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "- clusterIP: None\n" +
+		"  externalURLs: null\n" +
+		"  hostname: worker-env1.ns.svc.cluster.local\n" +
+		"  image: \"\"\n" +
+		"  name: worker\n" +
+		"  pod: \"\"\n" +
+		"  podIP: \"\"\n" +
+		"  ports: null\n" +
+		"  ready: false\n"
+	if buffer.String() != expected {
+		t.Errorf("%#v", buffer.String())
+	}
+}
+
+func TestWriteServiceDetails_GoTemplate(t *testing.T) {
+	for _, output := range []string{"go-template={{.Name}}={{.ClusterIP}}", "{{.Name}}={{.ClusterIP}}"} {
+		var buffer bytes.Buffer
+		err := writeServiceDetails(&buffer, newTestServiceDetails(), output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if buffer.String() != "my-db=10.0.0.1\nworker=None\n" {
+			t.Errorf("%#v", buffer.String())
+		}
+	}
+}
+
+func TestWriteServiceDetails_SingleTrailingNewline(t *testing.T) {
+	outputs := []string{"table", "json", "yaml", "{{.Name}}", "{{.Name}}\n", "go-template={{.Name}}\n\n"}
+	for _, output := range outputs {
+		var buffer bytes.Buffer
+		err := writeServiceDetails(&buffer, newTestServiceDetails()[1:], output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s := buffer.String()
+		if !strings.HasSuffix(s, "\n") || strings.HasSuffix(s, "\n\n") {
+			t.Errorf("output %#v: %#v", output, s)
+		}
+	}
+}
+
+func TestWriteServiceDetails_InvalidTemplate(t *testing.T) {
+	var buffer bytes.Buffer
+	err := writeServiceDetails(&buffer, newTestServiceDetails(), "go-template={{")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestWriteServiceDetailsDotenv(t *testing.T) {
+	var buffer bytes.Buffer
+	writeServiceDetailsDotenv(&buffer, newTestServiceDetails())
+	expected := "MY_DB_HOSTNAME=my-db-env1.ns.svc.cluster.local\n" +
+		"MY_DB_CLUSTER_IP=10.0.0.1\n" +
+		"MY_DB_POD_IP=172.17.0.2\n" +
+		"WORKER_HOSTNAME=worker-env1.ns.svc.cluster.local\n"
+	if buffer.String() != expected {
+		t.Errorf("%#v", buffer.String())
+	}
+}
+
+func TestGetDotenvPrefix(t *testing.T) {
+	if prefix := getDotenvPrefix("web.api-v2"); prefix != "WEB_API_V2" {
+		t.Error(prefix)
+	}
+}
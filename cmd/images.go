@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"context"
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/up"
+	"github.com/spf13/cobra"
+)
+
+const defaultLockFile = "kube-compose.lock"
+
+func newPullCli() *cobra.Command {
+	var pullCmd = &cobra.Command{
+		Use:   "pull [services...]",
+		Short: "Pull service images",
+		Long: "pulls the images of the specified docker compose services and their dependencies (and tags them if the cluster image " +
+			"storage is docker), and writes the resulting images to a lock file",
+		RunE: pullCommand,
+	}
+	addLockFileFlag(pullCmd, defaultLockFile, "The lock file to write")
+	addDaemonlessFlag(pullCmd)
+	return pullCmd
+}
+
+func newPushCli() *cobra.Command {
+	var pushCmd = &cobra.Command{
+		Use:   "push [services...]",
+		Short: "Push service images",
+		Long: "pulls the images of the specified docker compose services and their dependencies, pushes them to the cluster image " +
+			"storage, and writes the resulting images to a lock file that can be passed to up",
+		RunE: pushCommand,
+	}
+	addLockFileFlag(pushCmd, defaultLockFile, "The lock file to write")
+	return pushCmd
+}
+
+func pullCommand(cmd *cobra.Command, args []string) error {
+	return runImages(cmd, args, up.Pull)
+}
+
+func pushCommand(cmd *cobra.Command, args []string) error {
+	return runImages(cmd, args, up.Push)
+}
+
+func runImages(cmd *cobra.Command, args []string, run func(cfg *config.Config, opts *up.Options) (*up.Lock, error)) error {
+	cfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	opts := &up.Options{
+		Context:  context.Background(),
+		Reporter: newReporter(),
+	}
+	// Only the pull command has the daemonless flag.
+	opts.Daemonless, _ = cmd.Flags().GetBool("daemonless")
+	lock, err := run(cfg, opts)
+	if err == nil {
+		lockFile, _ := cmd.Flags().GetString("lock-file")
+		err = lock.WriteFile(lockFile)
+	}
+	opts.Reporter.Refresh()
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/logs"
+	"github.com/spf13/cobra"
+)
+
+func newLogsCli() *cobra.Command {
+	var logsCmd = &cobra.Command{
+		Use:   "logs [services...]",
+		Short: "View output from containers",
+		Long:  "prints the logs of all containers (including init containers) of the pods of the specified docker compose services",
+		RunE:  logsCommand,
+	}
+	// The shorthand -f is taken by the file flag of the root command.
+	logsCmd.PersistentFlags().Bool("follow", false, "Follow log output")
+	logsCmd.PersistentFlags().String("tail", "all", "Number of lines to show from the end of the logs of each container")
+	logsCmd.PersistentFlags().Duration("since", 0, "Only show logs newer than a relative duration (e.g. 10m)")
+	logsCmd.PersistentFlags().BoolP("timestamps", "t", false, "Show timestamps")
+	logsCmd.PersistentFlags().Bool("no-color", false, "Produce monochrome output")
+	logsCmd.PersistentFlags().BoolP("previous", "p", false, "Show the logs of the previous instance of each container")
+	return logsCmd
+}
+
+func parseTail(tail string) (*int64, error) {
+	if tail == "all" {
+		return nil, nil
+	}
+	n, err := strconv.ParseInt(tail, 10, 64)
+	if err != nil || n < 0 {
+		return nil, fmt.Errorf("invalid value %#v for flag tail, must be a non-negative integer or \"all\"", tail)
+	}
+	return &n, nil
+}
+
+func logsCommand(cmd *cobra.Command, args []string) error {
+	opts := &logs.Options{}
+	tail, _ := cmd.Flags().GetString("tail")
+	var err error
+	opts.Tail, err = parseTail(tail)
+	if err != nil {
+		return err
+	}
+	cfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	opts.Follow, _ = cmd.Flags().GetBool("follow")
+	opts.Since, _ = cmd.Flags().GetDuration("since")
+	opts.Timestamps, _ = cmd.Flags().GetBool("timestamps")
+	opts.NoColor, _ = cmd.Flags().GetBool("no-color")
+	opts.Previous, _ = cmd.Flags().GetBool("previous")
+	err = logs.Run(cfg, opts, os.Stdout)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	return nil
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import "testing"
+
+func TestParseTail_All(t *testing.T) {
+	tail, err := parseTail("all")
+	if err != nil || tail != nil {
+		t.Fail()
+	}
+}
+
+func TestParseTail_Success(t *testing.T) {
+	tail, err := parseTail("10")
+	if err != nil || tail == nil || *tail != 10 {
+		t.Fail()
+	}
+}
+
+func TestParseTail_Invalid(t *testing.T) {
+	for _, tail := range []string{"", "-1", "ten"} {
+		_, err := parseTail(tail)
+		if err == nil {
+			t.Error(tail)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/portforward"
+	"github.com/spf13/cobra"
+)
+
+func newPortForwardCli() *cobra.Command {
+	var portForwardCmd = &cobra.Command{
+		Use:   "port-forward [services...]",
+		Short: "Forward published ports to localhost",
+		Long: "forwards the published ports of the specified docker compose services (or of all docker compose services) from the host " +
+			"and port specified in the docker compose file to their pods, until interrupted",
+		RunE: portForwardCommand,
+	}
+	return portForwardCmd
+}
+
+func portForwardCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	stopChannel := make(chan struct{})
+	signalChannel := make(chan os.Signal, 1)
+	signal.Notify(signalChannel, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signalChannel
+		close(stopChannel)
+	}()
+	err = portforward.Run(cfg, &portforward.Options{
+		Out:         os.Stdout,
+		ErrOut:      os.Stderr,
+		StopChannel: stopChannel,
+	})
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	return nil
+}
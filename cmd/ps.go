@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/ps"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+func newPsCli() *cobra.Command {
+	var psCmd = &cobra.Command{
+		Use:   "ps [services...]",
+		Short: "List the pods of docker compose services",
+		Long:  "lists the pods of the specified docker compose services (or of all docker compose services) and their status",
+		RunE:  psCommand,
+	}
+	psCmd.PersistentFlags().String("format", "table", "Output format. One of table, json or a Go template that is executed for each pod")
+	psCmd.PersistentFlags().Bool("services", false, "Only print the names of the docker compose services that have pods")
+	psCmd.PersistentFlags().String("filter", "", "Only list pods that match the filter. Supported filters: status=<ready|started|"+
+		"completed|other|error>")
+	return psCmd
+}
+
+func parsePsFilter(filter string) (string, error) {
+	if filter == "" {
+		return "", nil
+	}
+	parts := strings.SplitN(filter, "=", 2)
+	if len(parts) != 2 || parts[0] != "status" {
+		return "", fmt.Errorf("invalid filter %#v, must be of the form status=<status>", filter)
+	}
+	return parts[1], nil
+}
+
+func formatPodDetailsTable(pods []*ps.PodDetails, now time.Time) string {
+	rows := [][]string{
+		{"SERVICE", "POD", "PHASE", "STATUS", "RESTARTS", "EXIT-CODE", "IMAGE", "PORTS", "AGE"},
+	}
+	for _, d := range pods {
+		exitCode := "<none>"
+		if d.ExitCode != nil {
+			exitCode = strconv.Itoa(int(*d.ExitCode))
+		}
+		rows = append(rows, []string{
+			d.Service,
+			d.Pod,
+			d.Phase,
+			d.Status,
+			strconv.Itoa(int(d.Restarts)),
+			exitCode,
+			d.Image,
+			strings.Join(d.Ports, ","),
+			now.Sub(d.CreatedAt).Truncate(time.Second).String(),
+		})
+	}
+	return util.FormatTable(rows)
+}
+
+func writePodDetails(w io.Writer, pods []*ps.PodDetails, format string, servicesOnly bool) error {
+	if servicesOnly {
+		seen := map[string]bool{}
+		for _, d := range pods {
+			if !seen[d.Service] {
+				seen[d.Service] = true
+				fmt.Fprintln(w, d.Service)
+			}
+		}
+		return nil
+	}
+	switch format {
+	case "table":
+		fmt.Fprintln(w, formatPodDetailsTable(pods, time.Now()))
+		return nil
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if pods == nil {
+			pods = []*ps.PodDetails{}
+		}
+		return encoder.Encode(pods)
+	}
+	tmpl, err := template.New("format").Parse(format)
+	if err != nil {
+		return err
+	}
+	for _, d := range pods {
+		err = tmpl.Execute(w, d)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+func psCommand(cmd *cobra.Command, args []string) error {
+	filter, _ := cmd.Flags().GetString("filter")
+	status, err := parsePsFilter(filter)
+	if err != nil {
+		return err
+	}
+	cfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	pods, err := ps.Run(cfg)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	if status != "" {
+		var filtered []*ps.PodDetails
+		for _, d := range pods {
+			if d.Status == status {
+				filtered = append(filtered, d)
+			}
+		}
+		pods = filtered
+	}
+	format, _ := cmd.Flags().GetString("format")
+	servicesOnly, _ := cmd.Flags().GetBool("services")
+	return writePodDetails(os.Stdout, pods, format, servicesOnly)
+}
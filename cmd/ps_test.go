@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/ps"
+)
+
+func newTestPodDetails() []*ps.PodDetails {
+	return []*ps.PodDetails{
+		{
+			Service:   "a",
+			Pod:       "a-env1",
+			Phase:     "Running",
+			Status:    "ready",
+			Image:     "ubuntu:latest",
+			Ports:     []string{"8080/tcp"},
+			CreatedAt: time.Unix(0, 0),
+		},
+		{
+			Service:   "a",
+			Pod:       "a-env1-2",
+			Phase:     "Running",
+			Status:    "started",
+			Image:     "ubuntu:latest",
+			CreatedAt: time.Unix(0, 0),
+		},
+	}
+}
+
+func TestParsePsFilter_Success(t *testing.T) {
+	status, err := parsePsFilter("status=ready")
+	if err != nil || status != "ready" {
+		t.Fail()
+	}
+}
+
+func TestParsePsFilter_Error(t *testing.T) {
+	_, err := parsePsFilter("name=a")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestWritePodDetails_Services(t *testing.T) {
+	var buffer bytes.Buffer
+	err := writePodDetails(&buffer, newTestPodDetails(), "table", true)
+	if err != nil || buffer.String() != "a\n" {
+		t.Errorf("%q", buffer.String())
+	}
+}
+
+func TestWritePodDetails_Template(t *testing.T) {
+	var buffer bytes.Buffer
+	err := writePodDetails(&buffer, newTestPodDetails(), "{{.Pod}} {{.Status}}", false)
+	if err != nil || buffer.String() != "a-env1 ready\na-env1-2 started\n" {
+		t.Errorf("%q", buffer.String())
+	}
+}
+
+func TestWritePodDetails_JSONEmpty(t *testing.T) {
+	var buffer bytes.Buffer
+	err := writePodDetails(&buffer, nil, "json", false)
+	if err != nil || buffer.String() != "[]\n" {
+		t.Errorf("%q", buffer.String())
+	}
+}
+
+func TestFormatPodDetailsTable(t *testing.T) {
+	output := formatPodDetailsTable(newTestPodDetails()[:1], time.Unix(5, 0))
+	expected := "SERVICE  POD     PHASE    STATUS  RESTARTS  EXIT-CODE  IMAGE          PORTS     AGE\n" +
+		"a        a-env1  Running  ready   0         <none>     ubuntu:latest  8080/tcp  5s\n"
+	if output != expected {
+		t.Errorf("%q", output)
+	}
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/down"
+	"github.com/spf13/cobra"
+)
+
+func newRestartCli() *cobra.Command {
+	var restartCmd = &cobra.Command{
+		Use:   "restart [services...]",
+		Short: "Restart services",
+		Long: "deletes the pods of the specified docker compose services and their dependencies, and recreates them in an order that " +
+			"respects depends_on, waiting until they are ready",
+		RunE: restartCommand,
+	}
+	addTTLFlag(restartCmd)
+	addRunAsUserFlag(restartCmd)
+	addImagePullSecretFlag(restartCmd)
+	addDaemonlessFlag(restartCmd)
+	addLockFileFlag(restartCmd, "", "Use the images recorded in this lock file (as written by the pull and push commands)")
+	return restartCmd
+}
+
+func restartCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	err = down.Stop(cfg, true)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	runUp(cmd, cfg, true)
+	return nil
+}
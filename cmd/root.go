@@ -9,16 +9,22 @@ import (
 )
 
 const (
-	envVarPrefix        = "KUBECOMPOSE_"
-	fileFlagName        = "file"
-	namespaceEnvVarName = envVarPrefix + "NAMESPACE"
-	namespaceFlagName   = "namespace"
-	envIDEnvVarName     = envVarPrefix + "ENVID"
-	envIDFlagName       = "env-id"
+	createNamespaceFlagName = "create-namespace"
+	envVarPrefix            = "KUBECOMPOSE_"
+	fileFlagName            = "file"
+	namespaceEnvVarName     = envVarPrefix + "NAMESPACE"
+	namespaceFlagName       = "namespace"
+	envIDEnvVarName         = envVarPrefix + "ENVID"
+	envIDFlagName           = "env-id"
 )
 
 func Execute() error {
 	log.SetOutput(os.Stdout)
+	return newRootCli().Execute()
+}
+
+// newRootCli returns the root command with all subcommands.
+func newRootCli() *cobra.Command {
 	rootCmd := &cobra.Command{
 		Use:               "kube-compose",
 		Short:             "k8s",
@@ -26,15 +32,35 @@ func Execute() error {
 		Version:           "0.6.1",
 		PersistentPreRunE: setupLogging,
 	}
-	rootCmd.AddCommand(newDownCli(), newUpCli(), newGetCli())
+	rootCmd.AddCommand(
+		newDownCli(),
+		newUpCli(),
+		newGetCli(),
+		newGcCli(),
+		newEnvCli(),
+		newPsCli(),
+		newLogsCli(),
+		newExecCli(),
+		newRunCli(),
+		newPortForwardCli(),
+		newStopCli(),
+		newStartCli(),
+		newRestartCli(),
+		newWaitCli(),
+		newPullCli(),
+		newPushCli(),
+		newEventsCli(),
+	)
 	setRootCommandFlags(rootCmd)
-	return rootCmd.Execute()
+	return rootCmd
 }
 
 func setRootCommandFlags(rootCmd *cobra.Command) {
 	rootCmd.PersistentFlags().StringSliceP(fileFlagName, "f", []string{}, "Specify an alternate compose file")
 	rootCmd.PersistentFlags().StringP(namespaceFlagName, "n", "", fmt.Sprintf("namespace for environment. Can also be set via "+
 		"environment variable %s. Default to the namespace of the current kube config context", namespaceEnvVarName))
+	rootCmd.PersistentFlags().Bool(createNamespaceFlagName, false, "use a namespace that is created for the environment by up and "+
+		"deleted by down. The name of the namespace is the namespace for the environment suffixed with the environment ID")
 	rootCmd.PersistentFlags().StringP(envIDFlagName, "e", "", "used to isolate environments deployed to a shared namespace, "+
 		"by (1) using this value as a suffix of pod and service names and (2) using this value to isolate selectors. Either this flag or "+
 		fmt.Sprintf("the environment variable %s must be set", envIDEnvVarName))
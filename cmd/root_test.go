@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// executeRootCli executes the full command tree, so that flags of subcommands that clash with flags of the root command cause a panic.
+func executeRootCli(args ...string) (string, error) {
+	rootCmd := newRootCli()
+	var out bytes.Buffer
+	rootCmd.SetOutput(&out)
+	rootCmd.SetArgs(args)
+	err := rootCmd.Execute()
+	return out.String(), err
+}
+
+func TestRootCli_LogsHelp(t *testing.T) {
+	out, err := executeRootCli("logs", "--help")
+	if err != nil || !strings.Contains(out, "--follow") {
+		t.Error(out, err)
+	}
+}
+
+func TestRootCli_RunHelp(t *testing.T) {
+	out, err := executeRootCli("run", "--help")
+	if err != nil || !strings.Contains(out, "--env") {
+		t.Error(out, err)
+	}
+}
+
+func TestRootCli_Help(t *testing.T) {
+	var walk func(cmd *cobra.Command, args []string)
+	walk = func(cmd *cobra.Command, args []string) {
+		for _, subCmd := range cmd.Commands() {
+			subArgs := append(append([]string{}, args...), subCmd.Name())
+			if _, err := executeRootCli(append(subArgs, "--help")...); err != nil {
+				t.Error(subArgs, err)
+			}
+			walk(subCmd, subArgs)
+		}
+	}
+	walk(newRootCli(), nil)
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/up"
+	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
+	"github.com/spf13/cobra"
+)
+
+func newRunCli() *cobra.Command {
+	var runCmd = &cobra.Command{
+		Use:   "run [flags] <service> [command] [args...]",
+		Short: "Run a one-off command",
+		Long: "creates a one-off pod of a docker compose service after its depends_on conditions are satisfied, prints the output of the " +
+			"pod and exits with the exit code of its container, similar to docker-compose run",
+		Args: cobra.MinimumNArgs(1),
+		RunE: runCommand,
+	}
+	// Flags after the service belong to the command.
+	runCmd.Flags().SetInterspersed(false)
+	runCmd.Flags().Bool("rm", false, "Delete the pod after its container has terminated")
+	// The shorthand -e is taken by the env-id flag of the root command.
+	runCmd.Flags().StringArray("env", nil, "Set an environment variable (can be used multiple times)")
+	runCmd.Flags().String("entrypoint", "", "Override the entrypoint of the image (split on whitespace)")
+	return runCmd
+}
+
+func parseEnvironmentFlags(values []string) (map[string]string, error) {
+	environment := map[string]string{}
+	for _, value := range values {
+		parts := strings.SplitN(value, "=", 2)
+		if parts[0] == "" {
+			return nil, fmt.Errorf("invalid environment variable %#v, must be of the form KEY=VALUE", value)
+		}
+		if len(parts) == 1 {
+			// Similar to docker-compose, take the value from the environment of kube-compose.
+			environment[parts[0]] = os.Getenv(parts[0])
+		} else {
+			environment[parts[0]] = parts[1]
+		}
+	}
+	return environment, nil
+}
+
+func runCommand(cmd *cobra.Command, args []string) error {
+	envFlags, _ := cmd.Flags().GetStringArray("env")
+	environment, err := parseEnvironmentFlags(envFlags)
+	if err != nil {
+		return err
+	}
+	cfg, err := getCommandConfig(cmd, args[:1])
+	if err != nil {
+		return err
+	}
+	oneOffOpts := &up.OneOffOptions{
+		Command:     args[1:],
+		Environment: environment,
+		Service:     cfg.Services[args[0]],
+		Stdout:      os.Stdout,
+	}
+	oneOffOpts.Remove, _ = cmd.Flags().GetBool("rm")
+	if cmd.Flags().Changed("entrypoint") {
+		entrypoint, _ := cmd.Flags().GetString("entrypoint")
+		oneOffOpts.Entrypoint = strings.Fields(entrypoint)
+	}
+	opts := &up.Options{
+		Context: context.Background(),
+		// The output of the one-off pod is written to stdout, so progress is not reported.
+		Reporter: reporter.New(os.Stderr),
+	}
+	exitCode, err := up.RunOneOff(cfg, opts, oneOffOpts)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+	return nil
+}
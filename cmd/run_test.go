@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestParseEnvironmentFlags_Success(t *testing.T) {
+	os.Setenv("KUBE_COMPOSE_TEST_VAR", "v3")
+	defer os.Unsetenv("KUBE_COMPOSE_TEST_VAR")
+	environment, err := parseEnvironmentFlags([]string{"K1=v1", "K2=a=b", "KUBE_COMPOSE_TEST_VAR"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"K1":                    "v1",
+		"K2":                    "a=b",
+		"KUBE_COMPOSE_TEST_VAR": "v3",
+	}
+	if !reflect.DeepEqual(environment, expected) {
+		t.Error(environment)
+	}
+}
+
+func TestParseEnvironmentFlags_Invalid(t *testing.T) {
+	_, err := parseEnvironmentFlags([]string{"=v1"})
+	if err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+func newStartCli() *cobra.Command {
+	var startCmd = &cobra.Command{
+		Use:   "start [services...]",
+		Short: "Start services",
+		Long: "creates the pods of the specified docker compose services and their dependencies that do not exist, in an order that " +
+			"respects depends_on, without touching services that depend on them",
+		RunE: startCommand,
+	}
+	addTTLFlag(startCmd)
+	addRunAsUserFlag(startCmd)
+	addImagePullSecretFlag(startCmd)
+	addDaemonlessFlag(startCmd)
+	addLockFileFlag(startCmd, "", "Use the images recorded in this lock file (as written by the pull and push commands)")
+	return startCmd
+}
+
+func startCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	runUp(cmd, cfg, true)
+	return nil
+}
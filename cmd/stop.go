@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/down"
+	"github.com/spf13/cobra"
+)
+
+func newStopCli() *cobra.Command {
+	var stopCmd = &cobra.Command{
+		Use:     "stop [services...]",
+		Aliases: []string{"rm"},
+		Short:   "Delete the pods of services, but keep their Kubernetes services",
+		Long: "deletes the pods (and controllers) of the specified docker compose services, but not those of their dependencies, and waits " +
+			"until the pods no longer exist. Kubernetes services are kept, so that the host aliases of other pods remain valid. Since pods " +
+			"cannot be stopped, rm is an alias of stop.",
+		RunE: stopCommand,
+	}
+	return stopCmd
+}
+
+func stopCommand(cmd *cobra.Command, args []string) error {
+	cfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	err = down.Stop(cfg, false)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
 	"github.com/kube-compose/kube-compose/internal/app/up"
 	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
 	"github.com/spf13/cobra"
@@ -19,38 +20,94 @@ func newUpCli() *cobra.Command {
 		RunE:  upCommand,
 	}
 	upCmd.PersistentFlags().BoolP("detach", "d", false, "Detached mode: Run containers in the background")
-	upCmd.PersistentFlags().BoolP("run-as-user", "", false, "When set, the runAsUser/runAsGroup will be set for each pod based on the "+
-		"user of the pod's image and the \"user\" key of the pod's docker-compose service")
+	upCmd.PersistentFlags().Bool("remove-orphans", false, "Delete the resources of services that are not in the docker compose file")
+	addTTLFlag(upCmd)
+	addRunAsUserFlag(upCmd)
+	addImagePullSecretFlag(upCmd)
+	addDaemonlessFlag(upCmd)
+	addLockFileFlag(upCmd, "", "Use the images recorded in this lock file (as written by the pull and push commands)")
 	return upCmd
 }
 
+func addTTLFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().Duration("ttl", 0, "The time to live of the environment (e.g. 2h), after which the environment is deleted "+
+		"by the gc command")
+}
+
+func addLockFileFlag(cmd *cobra.Command, value, usage string) {
+	cmd.PersistentFlags().String("lock-file", value, usage)
+}
+
+func addDaemonlessFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().Bool("daemonless", false, "Inspect images through the API of their docker registries instead of pulling them, "+
+		"and reference images by digest (the docker daemon is still used to tag, push and build images for the cluster image storage)")
+}
+
+func addImagePullSecretFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().Bool("image-pull-secret", false, "Create a secret with the credentials of the docker registries that pods "+
+		"pull images from (taken from the docker config file), and add it to the imagePullSecrets of each pod")
+}
+
+func addRunAsUserFlag(cmd *cobra.Command) {
+	cmd.PersistentFlags().BoolP("run-as-user", "", false, "When set, the runAsUser/runAsGroup will be set for each pod based on the "+
+		"user of the pod's image and the \"user\" key of the pod's docker-compose service")
+}
+
 func upCommand(cmd *cobra.Command, args []string) error {
 	cfg, err := getCommandConfig(cmd, args)
 	if err != nil {
 		return err
 	}
+	detach, _ := cmd.Flags().GetBool("detach")
+	runUp(cmd, cfg, detach)
+	return nil
+}
+
+// runUp runs up and exits if an error occurs. The command must have the ttl, run-as-user, image-pull-secret, daemonless and lock-file
+// flags.
+func runUp(cmd *cobra.Command, cfg *config.Config, detach bool) {
+	cfg.TTL, _ = cmd.Flags().GetDuration("ttl")
 	opts := &up.Options{}
 	opts.Context = context.Background()
-	opts.Detach, _ = cmd.Flags().GetBool("detach")
+	opts.Detach = detach
 	opts.RunAsUser, _ = cmd.Flags().GetBool("run-as-user")
+	opts.ImagePullSecret, _ = cmd.Flags().GetBool("image-pull-secret")
+	opts.Daemonless, _ = cmd.Flags().GetBool("daemonless")
+	// Only the up command has the remove-orphans flag.
+	opts.RemoveOrphans, _ = cmd.Flags().GetBool("remove-orphans")
+	opts.Reporter = newReporter()
 
-	opts.Reporter = reporter.New(os.Stdout)
-	if opts.Reporter.IsTerminal() {
-		log.StandardLogger().SetOutput(opts.Reporter.LogSink())
-		go func() {
-			for {
-				opts.Reporter.Refresh()
-				time.Sleep(reporter.RefreshInterval)
-			}
-		}()
+	lockFile, _ := cmd.Flags().GetString("lock-file")
+	if lockFile != "" {
+		lock, err := up.ReadLockFile(lockFile)
+		if err != nil {
+			log.Error(err)
+			os.Exit(1)
+		}
+		opts.Lock = lock
 	}
 
-	err = up.Run(cfg, opts)
+	err := up.Run(cfg, opts)
 	if err != nil {
 		log.Error(err)
 		opts.Reporter.Refresh()
 		os.Exit(1)
 	}
 	opts.Reporter.Refresh()
-	return nil
+}
+
+// newReporter creates a reporter that writes to stdout. If stdout is a terminal then logs are redirected through the reporter, and the
+// reporter is refreshed periodically.
+func newReporter() *reporter.Reporter {
+	r := reporter.New(os.Stdout)
+	if r.IsTerminal() {
+		log.StandardLogger().SetOutput(r.LogSink())
+		go func() {
+			for {
+				r.Refresh()
+				time.Sleep(reporter.RefreshInterval)
+			}
+		}()
+	}
+	return r
 }
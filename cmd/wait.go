@@ -0,0 +1,43 @@
+package cmd
+
+import (
+	"os"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/wait"
+	"github.com/spf13/cobra"
+)
+
+func newWaitCli() *cobra.Command {
+	var waitCmd = &cobra.Command{
+		Use:   "wait [services...]",
+		Short: "Wait until services reach a condition",
+		Long: "blocks until the pods of the specified docker compose services (or of all docker compose services) reach a condition, and " +
+			"exits with a non-zero exit code if a pod fails or the timeout expires",
+		RunE: waitCommand,
+	}
+	waitCmd.PersistentFlags().String("for", "ready", "The condition to wait for. One of ready, started and completed")
+	waitCmd.PersistentFlags().Duration("timeout", 0, "The maximum duration to wait (e.g. 5m). Zero means no timeout")
+	return waitCmd
+}
+
+func waitCommand(cmd *cobra.Command, args []string) error {
+	condition, _ := cmd.Flags().GetString("for")
+	opts := &wait.Options{}
+	var err error
+	opts.Condition, err = wait.ParseCondition(condition)
+	if err != nil {
+		return err
+	}
+	opts.Timeout, _ = cmd.Flags().GetDuration("timeout")
+	cfg, err := getCommandConfig(cmd, args)
+	if err != nil {
+		return err
+	}
+	err = wait.Run(cfg, opts)
+	if err != nil {
+		log.Error(err)
+		os.Exit(1)
+	}
+	return nil
+}
@@ -2,6 +2,9 @@ package config
 
 import (
 	"fmt"
+	"strings"
+	"text/template"
+	"time"
 
 	log "github.com/Sirupsen/logrus"
 	"github.com/kube-compose/kube-compose/internal/pkg/util"
@@ -12,22 +15,94 @@ import (
 	"k8s.io/client-go/rest"
 )
 
+// The possible values of Config.Workload and Service.Workload.
+const (
+	// WorkloadPod denotes that a docker compose service is run as a bare Pod.
+	WorkloadPod = "pod"
+	// WorkloadController denotes that a docker compose service is run by a Deployment, or by a Job if the docker compose service has
+	// restart "no" or "on-failure".
+	WorkloadController = "controller"
+)
+
+// The possible values of Config.ServiceDiscovery.
+const (
+	// ServiceDiscoveryHostAliases denotes that pods resolve docker compose services through host aliases of cluster IPs. This is the
+	// default.
+	ServiceDiscoveryHostAliases = "host_aliases"
+	// ServiceDiscoveryDNS denotes that pods resolve docker compose services through cluster DNS. Kubernetes Services are named exactly like
+	// the docker compose services, so environments should not share a namespace.
+	ServiceDiscoveryDNS = "dns"
+)
+
+// The possible values of DockerRegistryClusterImageStorage.Auth.
+const (
+	// RegistryAuthKubeBearerToken denotes that the bearer token of the kube config is used as the password of the docker registry, as
+	// required by the OpenShift integrated docker registry. This is the default.
+	RegistryAuthKubeBearerToken = "kube_bearer_token"
+	// RegistryAuthDockerConfig denotes that credentials are taken from the docker config file (e.g. ~/.docker/config.json).
+	RegistryAuthDockerConfig = "docker_config"
+	// RegistryAuthNone denotes that the docker registry does not require authentication.
+	RegistryAuthNone = "none"
+)
+
+// RepositoryTemplateData is the data of DockerRegistryClusterImageStorage.Repository.
+type RepositoryTemplateData struct {
+	EnvironmentID string
+	Namespace     string
+	// The escaped name of the docker compose service.
+	Service string
+}
+
 type DockerRegistryClusterImageStorage struct {
+	// One of RegistryAuthKubeBearerToken, RegistryAuthDockerConfig and RegistryAuthNone. Defaults to RegistryAuthKubeBearerToken.
+	Auth string
+	// If not empty, the file with PEM encoded CA certificates used to verify the docker registry when kube-compose accesses the docker
+	// registry directly. Pushes are done by the docker daemon, which has its own configuration.
+	CAFile string
+	// The host that images are pushed to.
 	Host string
+	// If true, kube-compose accesses the docker registry directly without verifying its certificate, or over plain HTTP.
+	Insecure bool
+	// The host of the docker registry as seen by the cluster, which is used in the images of pods. Defaults to the host of the OpenShift
+	// integrated docker registry.
+	PullHost string
+	// A Go template of the name of the repository of a docker compose service, that is executed with RepositoryTemplateData. Defaults to
+	// {{.Namespace}}/{{.Service}}.
+	Repository string
 }
 
 type Service struct {
-	DockerComposeService  *dockerComposeConfig.Service
+	DockerComposeService *dockerComposeConfig.Service
+	// How the published ports of this service are exposed outside the cluster. Defaults to Config.Expose.
+	Expose                *Expose
 	matchesFilter         bool
 	matchesFilterDirectly bool
 	NameEscaped           string
 	Ports                 []Port
+	// Kubernetes specific settings of the pod(s) of this service, set through "x-kube-compose".
+	PodOverrides PodOverrides
+	// One of WorkloadPod and WorkloadController. Defaults to Config.Workload.
+	Workload string
 }
 
 func (s *Service) Name() string {
 	return s.DockerComposeService.Name
 }
 
+// addPort adds a container port to the service, unless the service already has the port.
+func (s *Service) addPort(port int32, protocol string) {
+	p := Port{
+		Port:     port,
+		Protocol: protocol,
+	}
+	for _, p2 := range s.Ports {
+		if p == p2 {
+			return
+		}
+	}
+	s.Ports = append(s.Ports, p)
+}
+
 type ClusterImageStorage struct {
 	Docker         *struct{}
 	DockerRegistry *DockerRegistryClusterImageStorage
@@ -41,7 +116,17 @@ type Config struct {
 	KubeConfig          *rest.Config
 	Namespace           string
 	ClusterImageStorage ClusterImageStorage
+	// If not nil, Namespace is created for this environment by up, and deleted by down (see SetEphemeralNamespace).
+	EphemeralNamespace *EphemeralNamespace
+	// One of ServiceDiscoveryHostAliases and ServiceDiscoveryDNS.
+	ServiceDiscovery string
+	// If positive, the time to live of the environment, after which the environment can be garbage collected.
+	TTL time.Duration
+	// How published ports are exposed outside the cluster. This is the default for services that do not set expose.
+	Expose              *Expose
 	VolumeInitBaseImage *string
+	// One of WorkloadPod and WorkloadController. This is the default for services that do not set a workload.
+	Workload string
 
 	Services map[string]*Service
 }
@@ -55,6 +140,11 @@ type Port struct {
 func New(files []string) (*Config, error) {
 	cfg := &Config{
 		EnvironmentLabel: "env",
+		Expose: &Expose{
+			Type: ExposeTypeNone,
+		},
+		ServiceDiscovery: ServiceDiscoveryHostAliases,
+		Workload:         WorkloadPod,
 	}
 	dcCfg, err := dockerComposeConfig.New(files)
 	if err != nil {
@@ -70,10 +160,10 @@ func New(files []string) (*Config, error) {
 			NameEscaped:          util.EscapeName(name),
 		}
 		for _, portBinding := range dcService.Ports {
-			service.Ports = append(service.Ports, Port{
-				Protocol: portBinding.Protocol,
-				Port:     portBinding.Internal,
-			})
+			service.addPort(portBinding.Internal, portBinding.Protocol)
+		}
+		for _, portBinding := range dcService.Expose {
+			service.addPort(portBinding.Internal, portBinding.Protocol)
 		}
 		cfg.Services[name] = service
 	}
@@ -81,24 +171,50 @@ func New(files []string) (*Config, error) {
 	if err != nil {
 		return nil, err
 	}
+	for _, service := range cfg.Services {
+		err = loadServiceXKubeCompose(service)
+		if err != nil {
+			return nil, err
+		}
+		if service.Expose == nil {
+			service.Expose = cfg.Expose
+		}
+		if service.Workload == "" {
+			service.Workload = cfg.Workload
+		}
+	}
 	return cfg, nil
 }
 
 type clusterImageStorage struct {
-	Type string  `mapdecode:"type"`
-	Host *string `mapdecode:"host"`
+	Auth       *string `mapdecode:"auth"`
+	CAFile     *string `mapdecode:"ca_file"`
+	Host       *string `mapdecode:"host"`
+	Insecure   *bool   `mapdecode:"insecure"`
+	PullHost   *string `mapdecode:"pull_host"`
+	Repository *string `mapdecode:"repository"`
+	Type       string  `mapdecode:"type"`
 }
 
 type xKubeCompose struct {
 	XKubeCompose struct {
 		ClusterImageStorage *clusterImageStorage `mapdecode:"cluster_image_storage"`
+		EphemeralNamespace  *ephemeralNamespace  `mapdecode:"ephemeral_namespace"`
+		Expose              *expose              `mapdecode:"expose"`
 		PushImages          *struct {
 			DockerRegistry string `mapdecode:"docker_registry"`
 		} `mapdecode:"push_images"`
-		VolumeInitBaseImage *string `mapdecode:"volume_init_base_image"`
+		ServiceDiscovery    *string                     `mapdecode:"service_discovery"`
+		Services            map[string]*serviceSettings `mapdecode:"services"`
+		VolumeInitBaseImage *string                     `mapdecode:"volume_init_base_image"`
+		Workload            *string                     `mapdecode:"workload"`
 	} `mapdecode:"x-kube-compose"`
 }
 
+type xKubeComposeService struct {
+	XKubeCompose *serviceSettings `mapdecode:"x-kube-compose"`
+}
+
 func loadXKubeCompose(cfg *Config, xPropertiesSlice []dockerComposeConfig.XProperties) error {
 	for i := len(xPropertiesSlice) - 1; i >= 0; i-- {
 		var x xKubeCompose
@@ -124,10 +240,95 @@ func loadXKubeCompose(cfg *Config, xPropertiesSlice []dockerComposeConfig.XPrope
 			}
 		}
 		cfg.VolumeInitBaseImage = x.XKubeCompose.VolumeInitBaseImage
+		if x.XKubeCompose.Workload != nil {
+			cfg.Workload, err = parseWorkload(*x.XKubeCompose.Workload, "\"x-kube-compose\".\"workload\"")
+			if err != nil {
+				return err
+			}
+		}
+		if x.XKubeCompose.EphemeralNamespace != nil {
+			cfg.EphemeralNamespace, err = parseEphemeralNamespace(x.XKubeCompose.EphemeralNamespace)
+			if err != nil {
+				return err
+			}
+		}
+		if x.XKubeCompose.ServiceDiscovery != nil {
+			cfg.ServiceDiscovery, err = parseServiceDiscovery(*x.XKubeCompose.ServiceDiscovery)
+			if err != nil {
+				return err
+			}
+		}
+		if x.XKubeCompose.Expose != nil {
+			cfg.Expose, err = parseExpose(x.XKubeCompose.Expose, "\"x-kube-compose\".\"expose\"")
+			if err != nil {
+				return err
+			}
+		}
+		err = loadXKubeComposeServices(cfg, x.XKubeCompose.Services)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func loadXKubeComposeServices(cfg *Config, services map[string]*serviceSettings) error {
+	for name, settings := range services {
+		service := cfg.Services[name]
+		if service == nil {
+			return fmt.Errorf("a docker compose file has an entry for a non-existent service at \"x-kube-compose\".\"services\".\"%s\"",
+				name)
+		}
+		if settings == nil {
+			continue
+		}
+		err := applyServiceSettings(service, settings, fmt.Sprintf("\"x-kube-compose\".\"services\".\"%s\"", name))
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
 
+// loadServiceXKubeCompose loads the "x-kube-compose" section of a docker compose service. Since the docker compose service's x- properties
+// are ordered such that later elements take precedence, they are processed in order. The "x-kube-compose" section of a docker compose
+// service takes precedence over "x-kube-compose"."services".
+func loadServiceXKubeCompose(service *Service) error {
+	for _, xProperties := range service.DockerComposeService.XProperties {
+		var x xKubeComposeService
+		err := mapdecode.Decode(&x, xProperties, mapdecode.IgnoreUnused(true))
+		if err != nil {
+			return errors.Wrapf(err, "error while parsing \"x-kube-compose\" of docker compose service %s", service.Name())
+		}
+		if x.XKubeCompose == nil {
+			continue
+		}
+		err = applyServiceSettings(service, x.XKubeCompose, fmt.Sprintf("\"services\".\"%s\".\"x-kube-compose\"", service.Name()))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func parseWorkload(workload, path string) (string, error) {
+	switch workload {
+	case WorkloadPod, WorkloadController:
+		return workload, nil
+	}
+	return "", fmt.Errorf("a docker compose file has an invalid value at %s: value must be one of \"%s\" and \"%s\"", path, WorkloadPod,
+		WorkloadController)
+}
+
+func parseServiceDiscovery(serviceDiscovery string) (string, error) {
+	switch serviceDiscovery {
+	case ServiceDiscoveryHostAliases, ServiceDiscoveryDNS:
+		return serviceDiscovery, nil
+	}
+	return "", fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"service_discovery\": value must be one of "+
+		"\"%s\" and \"%s\"", ServiceDiscoveryHostAliases, ServiceDiscoveryDNS)
+}
+
 func loadClusterImageStorage(cfg *Config, v *clusterImageStorage) error {
 	cfg.ClusterImageStorage.Docker = nil
 	cfg.ClusterImageStorage.DockerRegistry = nil
@@ -139,9 +340,14 @@ func loadClusterImageStorage(cfg *Config, v *clusterImageStorage) error {
 			return fmt.Errorf("a docker compose file is missing a required value at \"x-kube-compose\".\"cluster_image_storage\"." +
 				"\"host\"")
 		}
-		cfg.ClusterImageStorage.DockerRegistry = &DockerRegistryClusterImageStorage{
+		dockerRegistry := &DockerRegistryClusterImageStorage{
 			Host: *v.Host,
 		}
+		err := loadDockerRegistryClusterImageStorage(dockerRegistry, v)
+		if err != nil {
+			return err
+		}
+		cfg.ClusterImageStorage.DockerRegistry = dockerRegistry
 	default:
 		return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"cluster_image_storage\".\"type\": " +
 			"value must be one of \"docker\" and \"docker_registry\"")
@@ -149,6 +355,50 @@ func loadClusterImageStorage(cfg *Config, v *clusterImageStorage) error {
 	return nil
 }
 
+func loadDockerRegistryClusterImageStorage(dockerRegistry *DockerRegistryClusterImageStorage, v *clusterImageStorage) error {
+	if v.Auth != nil {
+		switch *v.Auth {
+		case RegistryAuthKubeBearerToken, RegistryAuthDockerConfig, RegistryAuthNone:
+			dockerRegistry.Auth = *v.Auth
+		default:
+			return fmt.Errorf("a docker compose file has an invalid value at \"x-kube-compose\".\"cluster_image_storage\".\"auth\": "+
+				"value must be one of %#v, %#v and %#v", RegistryAuthKubeBearerToken, RegistryAuthDockerConfig, RegistryAuthNone)
+		}
+	}
+	if v.CAFile != nil {
+		dockerRegistry.CAFile = *v.CAFile
+	}
+	if v.Insecure != nil {
+		dockerRegistry.Insecure = *v.Insecure
+	}
+	if v.PullHost != nil {
+		dockerRegistry.PullHost = *v.PullHost
+	}
+	if v.Repository != nil {
+		_, err := ExecuteRepositoryTemplate(*v.Repository, &RepositoryTemplateData{})
+		if err != nil {
+			return errors.Wrap(err, "a docker compose file has an invalid value at \"x-kube-compose\".\"cluster_image_storage\"."+
+				"\"repository\"")
+		}
+		dockerRegistry.Repository = *v.Repository
+	}
+	return nil
+}
+
+// ExecuteRepositoryTemplate executes a template of the name of a repository (see DockerRegistryClusterImageStorage.Repository).
+func ExecuteRepositoryTemplate(repositoryTemplate string, data *RepositoryTemplateData) (string, error) {
+	t, err := template.New("repository").Option("missingkey=error").Parse(repositoryTemplate)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	err = t.Execute(&sb, data)
+	if err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
 // AddService adds a service to this configuration.
 func (cfg *Config) AddService(dockerComposeService *dockerComposeConfig.Service) *Service {
 	service := cfg.Services[dockerComposeService.Name]
@@ -160,7 +410,9 @@ func (cfg *Config) AddService(dockerComposeService *dockerComposeConfig.Service)
 		}
 		service = &Service{
 			DockerComposeService: dockerComposeService,
+			Expose:               cfg.Expose,
 			NameEscaped:          util.EscapeName(dockerComposeService.Name),
+			Workload:             cfg.Workload,
 		}
 		if cfg.Services == nil {
 			cfg.Services = map[string]*Service{}
@@ -341,6 +341,103 @@ x-kube-compose:
 	})
 }
 
+func Test_New_ClusterImageStorageDockerRegistryAllKeys(t *testing.T) {
+	file := "/dockerregistryallkeys"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+x-kube-compose:
+  cluster_image_storage:
+    type: docker_registry
+    auth: docker_config
+    ca_file: /ca.pem
+    host: registry.example.com
+    insecure: true
+    pull_host: registry.internal:5000
+    repository: team/{{.EnvironmentID}}-{{.Service}}
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+		} else {
+			expected := ClusterImageStorage{
+				DockerRegistry: &DockerRegistryClusterImageStorage{
+					Auth:       RegistryAuthDockerConfig,
+					CAFile:     "/ca.pem",
+					Host:       "registry.example.com",
+					Insecure:   true,
+					PullHost:   "registry.internal:5000",
+					Repository: "team/{{.EnvironmentID}}-{{.Service}}",
+				},
+			}
+			if !reflect.DeepEqual(c.ClusterImageStorage, expected) {
+				t.Logf("%+v\n", c.ClusterImageStorage.DockerRegistry)
+				t.Fail()
+			}
+		}
+	})
+}
+
+func Test_New_ClusterImageStorageDockerRegistryInvalidAuth(t *testing.T) {
+	file := "/dockerregistryinvalidauth"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+x-kube-compose:
+  cluster_image_storage:
+    type: docker_registry
+    host: registry.example.com
+    auth: invalid
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_ClusterImageStorageDockerRegistryInvalidRepository(t *testing.T) {
+	file := "/dockerregistryinvalidrepository"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+x-kube-compose:
+  cluster_image_storage:
+    type: docker_registry
+    host: registry.example.com
+    repository: "{{.Unknown}}"
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestExecuteRepositoryTemplate_Success(t *testing.T) {
+	actual, err := ExecuteRepositoryTemplate("{{.Namespace}}/{{.EnvironmentID}}-{{.Service}}", &RepositoryTemplateData{
+		EnvironmentID: "env1",
+		Namespace:     "ns1",
+		Service:       "a",
+	})
+	if err != nil || actual != "ns1/env1-a" {
+		t.Error(actual, err)
+	}
+}
+
+func TestExecuteRepositoryTemplate_ParseError(t *testing.T) {
+	_, err := ExecuteRepositoryTemplate("{{", &RepositoryTemplateData{})
+	if err == nil {
+		t.Fail()
+	}
+}
+
 func Test_New_ClusterImageStoragePushImagesAlsoSpecified(t *testing.T) {
 	file := "/pushimagesalsospecified"
 	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
@@ -360,3 +457,147 @@ x-kube-compose:
 		}
 	})
 }
+
+func Test_New_WorkloadSuccess(t *testing.T) {
+	file := "/workloadsuccess"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    image: ubuntu:latest
+  service2:
+    image: ubuntu:latest
+    x-kube-compose:
+      workload: pod
+x-kube-compose:
+  workload: controller
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+		} else {
+			if c.Workload != WorkloadController {
+				t.Fail()
+			}
+			if c.Services["service1"].Workload != WorkloadController {
+				t.Fail()
+			}
+			if c.Services["service2"].Workload != WorkloadPod {
+				t.Fail()
+			}
+		}
+	})
+}
+
+func Test_New_WorkloadDefault(t *testing.T) {
+	file := "/workloaddefault"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    image: ubuntu:latest
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+		} else if c.Services["service1"].Workload != WorkloadPod {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_WorkloadInvalid(t *testing.T) {
+	file := "/workloadinvalid"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+x-kube-compose:
+  workload: statefulset
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_ServiceWorkloadInvalid(t *testing.T) {
+	file := "/serviceworkloadinvalid"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    image: ubuntu:latest
+    x-kube-compose:
+      workload: statefulset
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_ServiceDiscoverySuccess(t *testing.T) {
+	file := "/servicediscoverysuccess"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+x-kube-compose:
+  service_discovery: dns
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+		} else if c.ServiceDiscovery != ServiceDiscoveryDNS {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_ServiceDiscoveryDefault(t *testing.T) {
+	file := "/servicediscoverydefault"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+		} else if c.ServiceDiscovery != ServiceDiscoveryHostAliases {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_ServiceDiscoveryInvalid(t *testing.T) {
+	file := "/servicediscoveryinvalid"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+x-kube-compose:
+  service_discovery: consul
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
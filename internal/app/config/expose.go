@@ -0,0 +1,81 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// The possible values of Expose.Type.
+const (
+	// ExposeTypeNone denotes that published ports are not reachable from outside the cluster. This is the default.
+	ExposeTypeNone = "none"
+	// ExposeTypeNodePort denotes that published ports are exposed through a Service of type NodePort.
+	ExposeTypeNodePort = "node_port"
+	// ExposeTypeLoadBalancer denotes that published ports are exposed through a Service of type LoadBalancer.
+	ExposeTypeLoadBalancer = "load_balancer"
+	// ExposeTypeIngress denotes that published ports are exposed through an Ingress.
+	ExposeTypeIngress = "ingress"
+)
+
+// Expose describes how the published ports of a docker compose service are exposed outside the cluster.
+type Expose struct {
+	// One of ExposeTypeNone, ExposeTypeNodePort, ExposeTypeLoadBalancer and ExposeTypeIngress.
+	Type string
+	// Only set if Type is ExposeTypeIngress. A template of the host of each Ingress rule (see IngressHostData).
+	IngressHost *template.Template
+}
+
+// IngressHostData is the data that is passed to Expose.IngressHost.
+type IngressHostData struct {
+	EnvironmentID string
+	Namespace     string
+	// The published port.
+	Port int32
+	// The escaped name of the docker compose service.
+	Service string
+}
+
+// FormatIngressHost formats the host of an Ingress rule of a published port of a docker compose service.
+func (cfg *Config) FormatIngressHost(expose *Expose, service *Service, port int32) (string, error) {
+	var buffer bytes.Buffer
+	err := expose.IngressHost.Execute(&buffer, &IngressHostData{
+		EnvironmentID: cfg.EnvironmentID,
+		Namespace:     cfg.Namespace,
+		Port:          port,
+		Service:       service.NameEscaped,
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "error while formatting the ingress host of service %s", service.Name())
+	}
+	return buffer.String(), nil
+}
+
+type expose struct {
+	IngressHost *string `mapdecode:"ingress_host"`
+	Type        string  `mapdecode:"type"`
+}
+
+func parseExpose(v *expose, path string) (*Expose, error) {
+	r := &Expose{
+		Type: v.Type,
+	}
+	switch v.Type {
+	case ExposeTypeNone, ExposeTypeNodePort, ExposeTypeLoadBalancer:
+	case ExposeTypeIngress:
+		if v.IngressHost == nil {
+			return nil, fmt.Errorf("a docker compose file is missing a required value at %s.\"ingress_host\"", path)
+		}
+		var err error
+		r.IngressHost, err = template.New("ingress_host").Option("missingkey=error").Parse(*v.IngressHost)
+		if err != nil {
+			return nil, errors.Wrapf(err, "a docker compose file has an invalid value at %s.\"ingress_host\"", path)
+		}
+	default:
+		return nil, fmt.Errorf("a docker compose file has an invalid value at %s.\"type\": value must be one of \"%s\", \"%s\", \"%s\" and "+
+			"\"%s\"", path, ExposeTypeNone, ExposeTypeNodePort, ExposeTypeLoadBalancer, ExposeTypeIngress)
+	}
+	return r, nil
+}
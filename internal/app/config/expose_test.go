@@ -0,0 +1,124 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func Test_New_ExposeSuccess(t *testing.T) {
+	file := "/exposesuccess"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    image: ubuntu:latest
+  service2:
+    image: ubuntu:latest
+    x-kube-compose:
+      expose:
+        type: ingress
+        ingress_host: '{{.Service}}-{{.Port}}-{{.EnvironmentID}}.example.com'
+x-kube-compose:
+  expose:
+    type: node_port
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		c.EnvironmentID = "env1"
+		if c.Services["service1"].Expose.Type != ExposeTypeNodePort {
+			t.Fail()
+		}
+		service2 := c.Services["service2"]
+		if service2.Expose.Type != ExposeTypeIngress {
+			t.Fail()
+		}
+		host, err := c.FormatIngressHost(service2.Expose, service2, 8080)
+		if err != nil {
+			t.Error(err)
+		} else if host != "service2-8080-env1.example.com" {
+			t.Error(host)
+		}
+	})
+}
+
+func Test_New_ExposeDefault(t *testing.T) {
+	file := "/exposedefault"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    image: ubuntu:latest
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+		} else if c.Services["service1"].Expose.Type != ExposeTypeNone {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_ExposeInvalidType(t *testing.T) {
+	file := "/exposeinvalidtype"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+x-kube-compose:
+  expose:
+    type: host_port
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_ExposeIngressHostMissing(t *testing.T) {
+	file := "/exposeingresshostmissing"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+x-kube-compose:
+  expose:
+    type: ingress
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_ExposeIngressHostInvalid(t *testing.T) {
+	file := "/exposeingresshostinvalid"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+x-kube-compose:
+  expose:
+    type: ingress
+    ingress_host: '{{.Service'
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// EphemeralNamespace describes the namespace that is created for an environment, so that environments do not share a namespace.
+type EphemeralNamespace struct {
+	// The hard limits of the ResourceQuota that is created in the namespace, or nil if no ResourceQuota should be created.
+	ResourceQuota v1.ResourceList
+}
+
+type ephemeralNamespace struct {
+	ResourceQuota map[string]string `mapdecode:"resource_quota"`
+}
+
+func parseEphemeralNamespace(v *ephemeralNamespace) (*EphemeralNamespace, error) {
+	r := &EphemeralNamespace{}
+	if len(v.ResourceQuota) > 0 {
+		r.ResourceQuota = v1.ResourceList{}
+		for name, value := range v.ResourceQuota {
+			quantity, err := resource.ParseQuantity(value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "a docker compose file has an invalid value at \"x-kube-compose\".\"ephemeral_namespace\"."+
+					"\"resource_quota\".\"%s\"", name)
+			}
+			r.ResourceQuota[v1.ResourceName(name)] = quantity
+		}
+	}
+	return r, nil
+}
+
+// SetEphemeralNamespace enables the ephemeral namespace (if it is not already enabled) and sets Namespace to the name of the ephemeral
+// namespace. The name of the ephemeral namespace is derived from Namespace and EnvironmentID, so EnvironmentID must be set first.
+func (cfg *Config) SetEphemeralNamespace() error {
+	if cfg.EphemeralNamespace == nil {
+		cfg.EphemeralNamespace = &EphemeralNamespace{}
+	}
+	namespace := cfg.Namespace + "-" + util.EscapeName(cfg.EnvironmentID)
+	if e := validation.IsDNS1123Label(namespace); len(e) > 0 {
+		return fmt.Errorf("the name %s of the ephemeral namespace is invalid: %s", namespace, e[0])
+	}
+	cfg.Namespace = namespace
+	return nil
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	v1 "k8s.io/api/core/v1"
+)
+
+func Test_New_EphemeralNamespaceSuccess(t *testing.T) {
+	file := "/ephemeralnamespacesuccess"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+x-kube-compose:
+  ephemeral_namespace:
+    resource_quota:
+      limits.memory: 4Gi
+      pods: '20'
+`),
+		},
+	}), func() {
+		c, err := New([]string{file})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		if c.EphemeralNamespace == nil {
+			t.Fatal()
+		}
+		memory := c.EphemeralNamespace.ResourceQuota[v1.ResourceLimitsMemory]
+		pods := c.EphemeralNamespace.ResourceQuota[v1.ResourcePods]
+		if memory.String() != "4Gi" || pods.Value() != 20 {
+			t.Error(c.EphemeralNamespace.ResourceQuota)
+		}
+	})
+}
+
+func Test_New_EphemeralNamespaceInvalidQuantity(t *testing.T) {
+	file := "/ephemeralnamespaceinvalidquantity"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+x-kube-compose:
+  ephemeral_namespace:
+    resource_quota:
+      pods: many
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestSetEphemeralNamespace_Success(t *testing.T) {
+	cfg := &Config{
+		EnvironmentID: "123",
+		Namespace:     "ci",
+	}
+	err := cfg.SetEphemeralNamespace()
+	if err != nil {
+		t.Error(err)
+	}
+	if cfg.Namespace != "ci-123" || cfg.EphemeralNamespace == nil {
+		t.Fail()
+	}
+}
+
+func TestSetEphemeralNamespace_Invalid(t *testing.T) {
+	cfg := &Config{
+		EnvironmentID: "123",
+		Namespace:     "a123456789b123456789c123456789d123456789e123456789f123456789",
+	}
+	err := cfg.SetEphemeralNamespace()
+	if err == nil {
+		t.Fail()
+	}
+}
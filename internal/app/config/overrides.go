@@ -0,0 +1,199 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// PodOverrides are Kubernetes specific settings of the pod(s) of a docker compose service.
+type PodOverrides struct {
+	// Annotations that are added to the pod(s).
+	Annotations map[string]string
+	// If not empty, overrides the image pull policy of the main container.
+	ImagePullPolicy   v1.PullPolicy
+	NodeSelector      map[string]string
+	PriorityClassName string
+	// Patches that are applied to the pod(s) in order, after all other settings have been applied.
+	Patches            []PodPatch
+	ServiceAccountName string
+	Tolerations        []v1.Toleration
+}
+
+// PodPatch is a patch that is applied to a generated pod.
+type PodPatch struct {
+	// The JSON encoded patch.
+	Data []byte
+	// One of types.StrategicMergePatchType and types.JSONPatchType.
+	Type types.PatchType
+}
+
+type toleration struct {
+	Effect            string `mapdecode:"effect"`
+	Key               string `mapdecode:"key"`
+	Operator          string `mapdecode:"operator"`
+	TolerationSeconds *int64 `mapdecode:"toleration_seconds"`
+	Value             string `mapdecode:"value"`
+}
+
+// serviceSettings is the schema of both "x-kube-compose"."services".<name> and "services".<name>."x-kube-compose".
+type serviceSettings struct {
+	Annotations         map[string]string `mapdecode:"annotations"`
+	Expose              *expose           `mapdecode:"expose"`
+	ImagePullPolicy     *string           `mapdecode:"image_pull_policy"`
+	JSONPatch           interface{}       `mapdecode:"json_patch"`
+	NodeSelector        map[string]string `mapdecode:"node_selector"`
+	PriorityClassName   *string           `mapdecode:"priority_class_name"`
+	ServiceAccountName  *string           `mapdecode:"service_account_name"`
+	StrategicMergePatch interface{}       `mapdecode:"strategic_merge_patch"`
+	Tolerations         []toleration      `mapdecode:"tolerations"`
+	Workload            *string           `mapdecode:"workload"`
+}
+
+// applyServiceSettings applies settings to a service, such that fields set in settings override those of the service. The keys of maps
+// are merged, and patches are appended. path is used to format errors.
+func applyServiceSettings(service *Service, settings *serviceSettings, path string) error {
+	var err error
+	if settings.Workload != nil {
+		service.Workload, err = parseWorkload(*settings.Workload, path+".\"workload\"")
+		if err != nil {
+			return err
+		}
+	}
+	if settings.Expose != nil {
+		service.Expose, err = parseExpose(settings.Expose, path+".\"expose\"")
+		if err != nil {
+			return err
+		}
+	}
+	o := &service.PodOverrides
+	o.Annotations = mergeStringMaps(o.Annotations, settings.Annotations)
+	if settings.ImagePullPolicy != nil {
+		o.ImagePullPolicy, err = parseImagePullPolicy(*settings.ImagePullPolicy, path+".\"image_pull_policy\"")
+		if err != nil {
+			return err
+		}
+	}
+	o.NodeSelector = mergeStringMaps(o.NodeSelector, settings.NodeSelector)
+	if settings.PriorityClassName != nil {
+		o.PriorityClassName = *settings.PriorityClassName
+	}
+	if settings.ServiceAccountName != nil {
+		o.ServiceAccountName = *settings.ServiceAccountName
+	}
+	if settings.Tolerations != nil {
+		o.Tolerations = make([]v1.Toleration, len(settings.Tolerations))
+		for i, t := range settings.Tolerations {
+			o.Tolerations[i] = v1.Toleration{
+				Effect:            v1.TaintEffect(t.Effect),
+				Key:               t.Key,
+				Operator:          v1.TolerationOperator(t.Operator),
+				TolerationSeconds: t.TolerationSeconds,
+				Value:             t.Value,
+			}
+		}
+	}
+	return applyServiceSettingsPatches(o, settings, path)
+}
+
+func applyServiceSettingsPatches(o *PodOverrides, settings *serviceSettings, path string) error {
+	if settings.StrategicMergePatch != nil {
+		data, err := marshalGeneric(settings.StrategicMergePatch)
+		if err != nil {
+			return errors.Wrapf(err, "a docker compose file has an invalid value at %s.\"strategic_merge_patch\"", path)
+		}
+		o.Patches = append(o.Patches, PodPatch{
+			Data: data,
+			Type: types.StrategicMergePatchType,
+		})
+	}
+	if settings.JSONPatch != nil {
+		data, err := marshalGeneric(settings.JSONPatch)
+		if err == nil {
+			_, err = jsonpatch.DecodePatch(data)
+		}
+		if err != nil {
+			return errors.Wrapf(err, "a docker compose file has an invalid value at %s.\"json_patch\"", path)
+		}
+		o.Patches = append(o.Patches, PodPatch{
+			Data: data,
+			Type: types.JSONPatchType,
+		})
+	}
+	return nil
+}
+
+func parseImagePullPolicy(imagePullPolicy, path string) (v1.PullPolicy, error) {
+	switch v1.PullPolicy(imagePullPolicy) {
+	case v1.PullAlways, v1.PullIfNotPresent, v1.PullNever:
+		return v1.PullPolicy(imagePullPolicy), nil
+	}
+	return "", fmt.Errorf("a docker compose file has an invalid value at %s: value must be one of \"%s\", \"%s\" and \"%s\"", path,
+		v1.PullAlways, v1.PullIfNotPresent, v1.PullNever)
+}
+
+func mergeStringMaps(into, from map[string]string) map[string]string {
+	if len(from) == 0 {
+		return into
+	}
+	if into == nil {
+		into = map[string]string{}
+	}
+	for k, v := range from {
+		into[k] = v
+	}
+	return into
+}
+
+// marshalGeneric JSON encodes a value decoded from YAML. This requires converting maps with interface{} keys to maps with string keys.
+func marshalGeneric(v interface{}) ([]byte, error) {
+	converted, err := convertGenericToJSONCompatible(v)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(converted)
+}
+
+func convertGenericToJSONCompatible(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for key, value := range t {
+			keyString, ok := key.(string)
+			if !ok {
+				return nil, fmt.Errorf("keys of maps must be strings but got %#v", key)
+			}
+			var err error
+			m[keyString], err = convertGenericToJSONCompatible(value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for key, value := range t {
+			var err error
+			m[key], err = convertGenericToJSONCompatible(value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+	case []interface{}:
+		s := make([]interface{}, len(t))
+		for i, value := range t {
+			var err error
+			s[i], err = convertGenericToJSONCompatible(value)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return s, nil
+	}
+	return v, nil
+}
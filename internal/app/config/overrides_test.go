@@ -0,0 +1,156 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func Test_New_PodOverridesMergeSuccess(t *testing.T) {
+	file1 := "/podoverrides1"
+	file2 := "/podoverrides2"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file1: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    image: ubuntu:latest
+    x-kube-compose:
+      node_selector:
+        disktype: ssd
+      service_account_name: sa1
+x-kube-compose:
+  services:
+    service1:
+      annotations:
+        key1: value1
+      image_pull_policy: IfNotPresent
+      priority_class_name: high
+      tolerations:
+      - key: dedicated
+        operator: Equal
+        value: ci
+        effect: NoSchedule
+`),
+		},
+		file2: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    x-kube-compose:
+      service_account_name: sa2
+      strategic_merge_patch:
+        spec:
+          hostNetwork: true
+      json_patch:
+      - op: add
+        path: /spec/hostname
+        value: myhost
+`),
+		},
+	}), func() {
+		c, err := New([]string{file1, file2})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		o := c.Services["service1"].PodOverrides
+		if !reflect.DeepEqual(o.Annotations, map[string]string{"key1": "value1"}) {
+			t.Fail()
+		}
+		if o.ImagePullPolicy != v1.PullIfNotPresent || o.PriorityClassName != "high" || o.ServiceAccountName != "sa2" {
+			t.Fail()
+		}
+		if !reflect.DeepEqual(o.NodeSelector, map[string]string{"disktype": "ssd"}) {
+			t.Fail()
+		}
+		expectedTolerations := []v1.Toleration{
+			{
+				Effect:   v1.TaintEffectNoSchedule,
+				Key:      "dedicated",
+				Operator: v1.TolerationOpEqual,
+				Value:    "ci",
+			},
+		}
+		if !reflect.DeepEqual(o.Tolerations, expectedTolerations) {
+			t.Fail()
+		}
+		if len(o.Patches) != 2 || o.Patches[0].Type != types.StrategicMergePatchType || o.Patches[1].Type != types.JSONPatchType {
+			t.Fail()
+		} else if string(o.Patches[0].Data) != `{"spec":{"hostNetwork":true}}` {
+			t.Error(string(o.Patches[0].Data))
+		}
+	})
+}
+
+func Test_New_PodOverridesNonExistentService(t *testing.T) {
+	file := "/podoverridesnonexistentservice"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+x-kube-compose:
+  services:
+    service1:
+      service_account_name: sa1
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_PodOverridesInvalidImagePullPolicy(t *testing.T) {
+	file := "/podoverridesinvalidimagepullpolicy"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    image: ubuntu:latest
+    x-kube-compose:
+      image_pull_policy: Sometimes
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_PodOverridesInvalidJSONPatch(t *testing.T) {
+	file := "/podoverridesinvalidjsonpatch"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    image: ubuntu:latest
+    x-kube-compose:
+      json_patch:
+        op: add
+`),
+		},
+	}), func() {
+		_, err := New([]string{file})
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func Test_ConvertGenericToJSONCompatible_InvalidKey(t *testing.T) {
+	_, err := convertGenericToJSONCompatible(map[interface{}]interface{}{
+		1: "value",
+	})
+	if err == nil {
+		t.Fail()
+	}
+}
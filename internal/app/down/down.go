@@ -1,23 +1,116 @@
 package down
 
 import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
 	log "github.com/Sirupsen/logrus"
 	"github.com/kube-compose/kube-compose/internal/app/config"
 	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	k8swatch "k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
-	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
 )
 
-type deleter func(name string, options *metav1.DeleteOptions) error
+// Options are the options of down.
+type Options struct {
+	// If not nil, the duration in seconds given to resources to terminate gracefully, overriding the default of each resource.
+	GracePeriodSeconds *int64
+	// True to delete orphans, i.e. resources of docker compose services that are not in the docker compose file (see RemoveOrphans).
+	RemoveOrphans bool
+	// One of RemoveImagesLocal and RemoveImagesAll to remove the images created by up, or the empty string.
+	RemoveImages string
+	// If positive, the maximum duration to wait for resources to be deleted.
+	Timeout time.Duration
+	// True to wait until deleted resources no longer exist.
+	Wait bool
+}
+
+// Resources are deleted in phases, in the order of the constants below. Resources of kinds in the same phase are deleted in parallel.
+const (
+	phaseControllers = iota
+	phasePods
+	phaseOther
+)
+
+// controllerResources are deleted before pods, otherwise they would recreate the pods.
+var controllerResources = map[string]bool{
+	"cronjobs":               true,
+	"daemonsets":             true,
+	"deployments":            true,
+	"jobs":                   true,
+	"replicasets":            true,
+	"replicationcontrollers": true,
+	"statefulsets":           true,
+}
+
+// ignoredResources are managed by Kubernetes.
+var ignoredResources = map[string]bool{
+	"endpoints": true,
+	"events":    true,
+}
+
+// NamespacesResource is the resource of namespaces, which are not namespaced and are therefore not returned by DiscoverResources.
+var NamespacesResource = schema.GroupVersionResource{
+	Version:  "v1",
+	Resource: "namespaces",
+}
+
+// GetPhase returns the phase in which resources of the resource are deleted. Resources of a lower phase are deleted first.
+func GetPhase(resource string) int {
+	switch {
+	case controllerResources[resource]:
+		return phaseControllers
+	case resource == "pods":
+		return phasePods
+	}
+	return phaseOther
+}
+
+// deletion records the deleted resources of a kind, so that down can wait until they no longer exist.
+type deletion struct {
+	client      dynamic.ResourceInterface
+	listOptions metav1.ListOptions
+	resource    string
+	// The names of deleted resources by UID. UIDs are used because resources may be recreated with the same name.
+	deleted map[types.UID]string
+}
 
-type lister func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error)
+func (del *deletion) names() []string {
+	var names []string
+	for _, name := range del.deleted {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
 
 type downRunner struct {
-	cfg              *config.Config
-	k8sClientset     *kubernetes.Clientset
-	k8sServiceClient clientV1.ServiceInterface
-	k8sPodClient     clientV1.PodInterface
+	cfg               *config.Config
+	k8sClientset      *kubernetes.Clientset
+	dynamicClient     dynamic.Interface
+	discoverResources func() ([]schema.GroupVersionResource, error)
+	opts              *Options
+	// If true, only orphans are deleted.
+	orphansOnly bool
+	// If true, a warning is logged for each orphan that is not deleted.
+	warnOrphans bool
+	// If true, only resources of docker compose services that match the filter directly are deleted, and not those of their dependencies
+	// (see MatchesFilterDirectly).
+	directOnly bool
+	mutex       sync.Mutex
+	// The UIDs of deleted resources. The same resource may be listed through multiple API groups (e.g. deployments in apps and
+	// extensions), but should only be deleted once.
+	deletedUIDs map[types.UID]bool
 }
 
 func (d *downRunner) initKubernetesClientset() error {
@@ -26,87 +119,407 @@ func (d *downRunner) initKubernetesClientset() error {
 		return err
 	}
 	d.k8sClientset = k8sClientset
-	d.k8sServiceClient = d.k8sClientset.CoreV1().Services(d.cfg.Namespace)
-	d.k8sPodClient = d.k8sClientset.CoreV1().Pods(d.cfg.Namespace)
+	dynamicClient, err := dynamic.NewForConfig(d.cfg.KubeConfig)
+	if err != nil {
+		return err
+	}
+	d.dynamicClient = dynamicClient
+	d.discoverResources = d.discoverResourcesFromServer
 	return nil
 }
 
-func (d *downRunner) deleteCommon(kind string, lister lister, deleter deleter) (bool, error) {
-	listOptions := metav1.ListOptions{
-		LabelSelector: d.cfg.EnvironmentLabel + "=" + d.cfg.EnvironmentID,
+func (d *downRunner) discoverResourcesFromServer() ([]schema.GroupVersionResource, error) {
+	return DiscoverResources(d.k8sClientset.Discovery())
+}
+
+// DiscoverResources returns every namespaced kind of resource that can be listed, watched and deleted, so that resources of any kind created
+// by up are deleted.
+func DiscoverResources(discoveryClient discovery.DiscoveryInterface) ([]schema.GroupVersionResource, error) {
+	lists, err := discoveryClient.ServerPreferredNamespacedResources()
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return nil, err
+		}
+		// Typically an aggregated API server is unavailable, so continue with the API groups that were discovered.
+		log.Warn(err)
 	}
-	list, err := lister(listOptions)
+	lists = discovery.FilteredBy(discovery.SupportsAllVerbs{
+		Verbs: []string{"delete", "list", "watch"},
+	}, lists)
+	gvrSet, err := discovery.GroupVersionResources(lists)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	var gvrs []schema.GroupVersionResource
+	for gvr := range gvrSet {
+		if !ignoredResources[gvr.Resource] {
+			gvrs = append(gvrs, gvr)
+		}
+	}
+	sort.Slice(gvrs, func(i, j int) bool {
+		return gvrs[i].String() < gvrs[j].String()
+	})
+	return gvrs, nil
+}
+
+// newListOptions selects the resources of the environment that were created by kube-compose, like gc, so that resources that users labelled
+// with the environment are kept.
+func (d *downRunner) newListOptions() metav1.ListOptions {
+	return metav1.ListOptions{
+		LabelSelector: d.cfg.EnvironmentLabel + "=" + d.cfg.EnvironmentID + "," + k8smeta.LabelCreatedAt,
+	}
+}
+
+func (d *downRunner) newDeleteOptions() *metav1.DeleteOptions {
+	// Use background propagation so that the pods of Deployments and Jobs are deleted as well.
+	propagationPolicy := metav1.DeletePropagationBackground
+	return &metav1.DeleteOptions{
+		GracePeriodSeconds: d.opts.GracePeriodSeconds,
+		PropagationPolicy:  &propagationPolicy,
+	}
+}
+
+// isOrphan returns true if the resource belongs to a docker compose service that is not in the docker compose file, for example because the
+// docker compose service was renamed or a different docker compose file was used.
+func (d *downRunner) isOrphan(obj metav1.Object) bool {
+	name, ok := obj.GetAnnotations()[k8smeta.AnnotationName]
+	return ok && d.cfg.Services[name] == nil
+}
+
+// matchesFilter returns true if the docker compose service matches the filter, or matches it directly if directOnly is set.
+func (d *downRunner) matchesFilter(composeService *config.Service) bool {
+	if d.directOnly {
+		return d.cfg.MatchesFilterDirectly(composeService)
+	}
+	return d.cfg.MatchesFilter(composeService)
+}
+
+// matchesAllServices returns true if all docker compose services match the filter.
+func (d *downRunner) matchesAllServices() bool {
+	for _, composeService := range d.cfg.Services {
+		if !d.matchesFilter(composeService) {
+			return false
+		}
+	}
+	return true
+}
+
+// isEnvironmentResource returns true if the resource is one that up creates for the environment instead of for a docker compose service.
+func (d *downRunner) isEnvironmentResource(obj metav1.Object) bool {
+	name := obj.GetName()
+	return name == k8smeta.GetImagePullSecretName(d.cfg) || name == k8smeta.ResourceQuotaName
+}
+
+// isToBeDeleted returns true if the resource is not an orphan and belongs to a docker compose service that matches the filter, or if the
+// resource is created by up for the environment and all docker compose services match the filter. Orphans are only deleted if
+// RemoveOrphans is set.
+func (d *downRunner) isToBeDeleted(obj metav1.Object) bool {
+	if d.isOrphan(obj) {
+		return d.opts.RemoveOrphans
+	}
+	if d.orphansOnly {
+		return false
+	}
+	composeService := k8smeta.FindFromObjectMeta(d.cfg, &metav1.ObjectMeta{
+		Annotations: obj.GetAnnotations(),
+	})
+	if composeService == nil {
+		return d.isEnvironmentResource(obj) && d.matchesAllServices()
+	}
+	return d.matchesFilter(composeService)
+}
+
+// claim returns true if the resource with the UID has not been deleted yet.
+func (d *downRunner) claim(uid types.UID) bool {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	if d.deletedUIDs[uid] {
+		return false
+	}
+	d.deletedUIDs[uid] = true
+	return true
+}
+
+// deleteResources deletes the resources of a kind that are to be deleted. It returns false if some resources are not to be deleted.
+func (d *downRunner) deleteResources(gvr schema.GroupVersionResource) (*deletion, bool, error) {
+	del := &deletion{
+		client:      d.dynamicClient.Resource(gvr).Namespace(d.cfg.Namespace),
+		listOptions: d.newListOptions(),
+		resource:    gvr.Resource,
+		deleted:     map[types.UID]string{},
+	}
+	list, err := del.client.List(del.listOptions)
+	if k8sError.IsForbidden(err) || k8sError.IsNotFound(err) || k8sError.IsMethodNotSupported(err) {
+		// kube-compose cannot have created resources of this kind.
+		log.Debugf("skipping %s: %v", gvr.Resource, err)
+		return del, true, nil
+	}
+	if err != nil {
+		return nil, false, err
 	}
-	deleteOptions := &metav1.DeleteOptions{}
 	deletedAll := true
-	for _, item := range list {
-		composeService := k8smeta.FindFromObjectMeta(d.cfg, item)
-		if composeService == nil || d.cfg.MatchesFilter(composeService) {
-			err = deleter(item.Name, deleteOptions)
-			if err != nil {
-				return false, err
+	for i := 0; i < len(list.Items); i++ {
+		item := &list.Items[i]
+		if !d.isToBeDeleted(item) {
+			if d.warnOrphans && d.isOrphan(item) {
+				log.Warnf("%s %s belongs to docker compose service %s, which is not in the docker compose file, use --remove-orphans to "+
+					"delete it", GetKind(item, gvr), item.GetName(), item.GetAnnotations()[k8smeta.AnnotationName])
 			}
-			log.Infof("deleted %s %s\n", kind, item.Name)
-		} else {
 			deletedAll = false
+			continue
+		}
+		if !d.claim(item.GetUID()) {
+			continue
 		}
+		err = del.client.Delete(item.GetName(), d.newDeleteOptions())
+		if k8sError.IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		log.Infof("deleted %s %s\n", GetKind(item, gvr), item.GetName())
+		del.deleted[item.GetUID()] = item.GetName()
+	}
+	return del, deletedAll, nil
+}
+
+// GetKind returns the kind of a listed resource, or the resource of the kind if the kind is not set.
+func GetKind(item *unstructured.Unstructured, gvr schema.GroupVersionResource) string {
+	if kind := item.GetKind(); kind != "" {
+		return kind
+	}
+	return gvr.Resource
+}
+
+// deletePhase deletes the resources of all kinds in the phase in parallel. It returns false if some resources are not to be deleted.
+func (d *downRunner) deletePhase(gvrs []schema.GroupVersionResource, phase int) ([]*deletion, bool, error) {
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var deletions []*deletion
+	var errs []error
+	deletedAll := true
+	for _, gvr := range gvrs {
+		if GetPhase(gvr.Resource) != phase {
+			continue
+		}
+		wg.Add(1)
+		go func(gvr schema.GroupVersionResource) {
+			defer wg.Done()
+			del, deletedAllOfKind, err := d.deleteResources(gvr)
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				errs = append(errs, err)
+				return
+			}
+			deletions = append(deletions, del)
+			deletedAll = deletedAll && deletedAllOfKind
+		}(gvr)
+	}
+	wg.Wait()
+	if len(errs) > 0 {
+		return nil, false, errs[0]
+	}
+	return deletions, deletedAll, nil
+}
+
+// deleteEphemeralNamespace deletes the namespace of the environment, which deletes all resources in it. It returns nil if the environment
+// does not have an ephemeral namespace, or if only some docker compose services are to be deleted.
+func (d *downRunner) deleteEphemeralNamespace() (*deletion, error) {
+	if d.cfg.EphemeralNamespace == nil || !d.matchesAllServices() {
+		return nil, nil
+	}
+	del := &deletion{
+		client: d.dynamicClient.Resource(NamespacesResource),
+		listOptions: metav1.ListOptions{
+			FieldSelector: "metadata.name=" + d.cfg.Namespace,
+		},
+		resource: NamespacesResource.Resource,
+		deleted:  map[types.UID]string{},
+	}
+	namespace, err := del.client.Get(d.cfg.Namespace, metav1.GetOptions{})
+	if k8sError.IsNotFound(err) {
+		return del, nil
 	}
-	return deletedAll, nil
+	if err != nil {
+		return nil, err
+	}
+	err = del.client.Delete(d.cfg.Namespace, d.newDeleteOptions())
+	if k8sError.IsNotFound(err) {
+		return del, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	log.Infof("deleted namespace %s\n", d.cfg.Namespace)
+	del.deleted[namespace.GetUID()] = d.cfg.Namespace
+	return del, nil
 }
 
-// Linter reports code duplication amongst deleteServices and deletePods. Although this is true, deduplicating would require the use of
-// generics, so we choose to nolint.
-// nolint
-func (d *downRunner) deleteServices() (bool, error) {
-	lister := func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error) {
-		serviceList, err := d.k8sServiceClient.List(listOptions)
+// waitForDeletion waits until the deleted resources of a kind no longer exist. Resources are not deleted immediately, for example because
+// pods are given time to terminate gracefully.
+func (d *downRunner) waitForDeletion(del *deletion, timeoutChannel <-chan struct{}) error {
+	if len(del.deleted) == 0 {
+		return nil
+	}
+	listOptions := del.listOptions
+	for {
+		// List again whenever the watch is closed by the server, since events may have been missed.
+		listOptions.ResourceVersion = ""
+		listOptions.Watch = false
+		list, err := del.client.List(listOptions)
 		if err != nil {
-			return nil, err
+			return err
+		}
+		remaining := map[types.UID]string{}
+		for i := 0; i < len(list.Items); i++ {
+			uid := list.Items[i].GetUID()
+			if name, ok := del.deleted[uid]; ok {
+				remaining[uid] = name
+			}
 		}
-		list := make([]*metav1.ObjectMeta, len(serviceList.Items))
-		for i := 0; i < len(serviceList.Items); i++ {
-			list[i] = &serviceList.Items[i].ObjectMeta
+		del.deleted = remaining
+		if len(del.deleted) == 0 {
+			return nil
+		}
+		log.Debugf("waiting for %d %s to be deleted", len(del.deleted), del.resource)
+		listOptions.ResourceVersion = list.GetResourceVersion()
+		listOptions.Watch = true
+		watch, err := del.client.Watch(listOptions)
+		if err != nil {
+			return err
+		}
+		done, err := d.watchDeletion(del, watch, timeoutChannel)
+		watch.Stop()
+		if err != nil || done {
+			return err
 		}
-		return list, nil
 	}
-	return d.deleteCommon("Service", lister, d.k8sServiceClient.Delete)
 }
 
-// Linter reports code duplication amongst deleteServices and deletePods. Although this is true, deduplicating would require the use of
-// generics, so we choose to nolint.
-// nolint
-func (d *downRunner) deletePods() (bool, error) {
-	lister := func(listOptions metav1.ListOptions) ([]*metav1.ObjectMeta, error) {
-		podList, err := d.k8sPodClient.List(listOptions)
-		if err != nil {
-			return nil, err
+// watchDeletion processes events until the deleted resources of a kind no longer exist. Returns false if the watch was closed by the server
+// before all resources were deleted.
+func (d *downRunner) watchDeletion(del *deletion, watch k8swatch.Interface, timeoutChannel <-chan struct{}) (bool, error) {
+	eventChannel := watch.ResultChan()
+	for {
+		select {
+		case event, ok := <-eventChannel:
+			if !ok {
+				return false, nil
+			}
+			switch event.Type {
+			case k8swatch.Added, k8swatch.Modified:
+			case k8swatch.Deleted:
+				if obj, ok := event.Object.(*unstructured.Unstructured); ok {
+					delete(del.deleted, obj.GetUID())
+				}
+			default:
+				// Typically the resource version is too old, so list again.
+				log.Debugf("error event while watching %s: %+v", del.resource, event.Object)
+				return false, nil
+			}
+			if len(del.deleted) == 0 {
+				return true, nil
+			}
+		case <-timeoutChannel:
+			return false, fmt.Errorf("timed out waiting for %s to be deleted: %s", del.resource, strings.Join(del.names(), ", "))
 		}
-		list := make([]*metav1.ObjectMeta, len(podList.Items))
-		for i := 0; i < len(podList.Items); i++ {
-			list[i] = &podList.Items[i].ObjectMeta
+	}
+}
+
+// waitForDeletions waits in parallel until deleted resources no longer exist, or until the timeout expires.
+func (d *downRunner) waitForDeletions(deletions []*deletion) error {
+	timeoutChannel := make(chan struct{})
+	if d.opts.Timeout > 0 {
+		timer := time.AfterFunc(d.opts.Timeout, func() {
+			close(timeoutChannel)
+		})
+		defer timer.Stop()
+	}
+	errChannel := make(chan error, len(deletions))
+	for _, del := range deletions {
+		go func(del *deletion) {
+			errChannel <- d.waitForDeletion(del, timeoutChannel)
+		}(del)
+	}
+	var firstErr error
+	for range deletions {
+		if err := <-errChannel; err != nil && firstErr == nil {
+			firstErr = err
 		}
-		return list, nil
 	}
-	return d.deleteCommon("Pod", lister, d.k8sPodClient.Delete)
+	return firstErr
 }
 
-func (d *downRunner) run() error {
+// deleteControllersAndPods returns true if all pods of the environment were deleted.
+func (d *downRunner) deleteControllersAndPods(gvrs []schema.GroupVersionResource) ([]*deletion, bool, error) {
+	deletions, _, err := d.deletePhase(gvrs, phaseControllers)
+	if err != nil {
+		return nil, false, err
+	}
+	podDeletions, deletedAllPods, err := d.deletePhase(gvrs, phasePods)
+	if err != nil {
+		return nil, false, err
+	}
+	return append(deletions, podDeletions...), deletedAllPods, nil
+}
+
+func (d *downRunner) stop() error {
 	err := d.initKubernetesClientset()
 	if err != nil {
 		return err
 	}
-
-	deletedAllPods, err := d.deletePods()
+	gvrs, err := d.discoverResources()
+	if err != nil {
+		return err
+	}
+	deletions, _, err := d.deleteControllersAndPods(gvrs)
 	if err != nil {
 		return err
 	}
+	return d.waitForDeletions(deletions)
+}
 
-	// Only delete services if all pods are to be deleted. This is so that existing pods will not have
+func (d *downRunner) deleteAll() ([]*deletion, error) {
+	namespaceDeletion, err := d.deleteEphemeralNamespace()
+	if err != nil {
+		return nil, err
+	}
+	if namespaceDeletion != nil {
+		return []*deletion{namespaceDeletion}, nil
+	}
+	gvrs, err := d.discoverResources()
+	if err != nil {
+		return nil, err
+	}
+	deletions, deletedAllPods, err := d.deleteControllersAndPods(gvrs)
+	if err != nil {
+		return nil, err
+	}
+	// Only delete other resources (such as Services) if all pods are to be deleted. This is so that existing pods will not have
 	// their host aliases invalidated.
 	if deletedAllPods {
-		_, err = d.deleteServices()
+		otherDeletions, _, err := d.deletePhase(gvrs, phaseOther)
+		if err != nil {
+			return nil, err
+		}
+		deletions = append(deletions, otherDeletions...)
+	}
+	return deletions, nil
+}
+
+func (d *downRunner) removeOrphans() error {
+	err := d.initKubernetesClientset()
+	if err != nil {
+		return err
+	}
+	gvrs, err := d.discoverResources()
+	if err != nil {
+		return err
+	}
+	for phase := phaseControllers; phase <= phaseOther; phase++ {
+		_, _, err = d.deletePhase(gvrs, phase)
 		if err != nil {
 			return err
 		}
@@ -114,10 +527,60 @@ func (d *downRunner) run() error {
 	return nil
 }
 
-// Run runs a docker-compose down command...
-func Run(cfg *config.Config) error {
-	d := &downRunner{
-		cfg: cfg,
+func (d *downRunner) run() error {
+	err := d.initKubernetesClientset()
+	if err != nil {
+		return err
+	}
+	deletions, err := d.deleteAll()
+	if err != nil {
+		return err
+	}
+	// Pods are waited for before removing images, since the docker daemon does not remove images that are in use.
+	if d.opts.Wait || d.opts.RemoveImages != "" {
+		err = d.waitForDeletions(deletions)
+		if err != nil {
+			return err
+		}
 	}
+	if d.opts.RemoveImages != "" {
+		return d.removeImages()
+	}
+	return nil
+}
+
+func newDownRunner(cfg *config.Config, opts *Options) *downRunner {
+	return &downRunner{
+		cfg:         cfg,
+		opts:        opts,
+		deletedUIDs: map[types.UID]bool{},
+	}
+}
+
+// Run runs a docker-compose down command. Resources of every kind that carry the environment label are deleted, except that resources
+// other than controllers and pods are only deleted if all pods of the environment are deleted. Orphans are only deleted if RemoveOrphans
+// is set, otherwise a warning is logged.
+func Run(cfg *config.Config, opts *Options) error {
+	d := newDownRunner(cfg, opts)
+	d.warnOrphans = true
 	return d.run()
 }
+
+// RemoveOrphans deletes the resources of the environment that belong to docker compose services that are not in the docker compose file,
+// without waiting until they no longer exist.
+func RemoveOrphans(cfg *config.Config) error {
+	d := newDownRunner(cfg, &Options{
+		RemoveOrphans: true,
+	})
+	d.orphansOnly = true
+	return d.removeOrphans()
+}
+
+// Stop deletes the pods (and controllers) of the docker compose services that match the filter, and waits until they no longer exist.
+// Unlike Run, Kubernetes Services and Ingresses are never deleted, so that the host aliases of other pods remain valid. If withDependencies
+// is false then only the pods of docker compose services that match the filter directly are deleted (see MatchesFilterDirectly).
+func Stop(cfg *config.Config, withDependencies bool) error {
+	d := newDownRunner(cfg, &Options{})
+	d.directOnly = !withDependencies
+	return d.stop()
+}
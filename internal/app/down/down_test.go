@@ -0,0 +1,339 @@
+package down
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func newTestObjectMeta(service string) *metav1.ObjectMeta {
+	return &metav1.ObjectMeta{
+		Annotations: map[string]string{
+			k8smeta.AnnotationName: service,
+		},
+	}
+}
+
+func TestDownRunnerIsToBeDeleted(t *testing.T) {
+	cfg := &config.Config{}
+	a := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	cfg.AddService(&dockerComposeConfig.Service{
+		Name: "b",
+	})
+	cfg.AddService(&dockerComposeConfig.Service{
+		Name: "c",
+	})
+	a.DockerComposeService.DependsOn = map[string]dockerComposeConfig.ServiceHealthiness{
+		"b": dockerComposeConfig.ServiceStarted,
+	}
+	cfg.AddToFilter(a)
+	d := newDownRunner(cfg, &Options{})
+	if !d.isToBeDeleted(newTestObjectMeta("a")) {
+		t.Fail()
+	}
+	// Dependencies match the filter as well.
+	if !d.isToBeDeleted(newTestObjectMeta("b")) {
+		t.Fail()
+	}
+	if d.isToBeDeleted(newTestObjectMeta("c")) {
+		t.Fail()
+	}
+	// Orphans are only deleted if RemoveOrphans is set.
+	if d.isToBeDeleted(newTestObjectMeta("d")) {
+		t.Fail()
+	}
+	d.opts.RemoveOrphans = true
+	if !d.isToBeDeleted(newTestObjectMeta("d")) {
+		t.Fail()
+	}
+	// Resources that up creates for the environment are only deleted if all docker compose services are.
+	imagePullSecret := &metav1.ObjectMeta{
+		Name: "kube-compose-image-pull-",
+	}
+	if d.isToBeDeleted(imagePullSecret) {
+		t.Fail()
+	}
+	cfg.AddToFilter(cfg.Services["c"])
+	if !d.isToBeDeleted(imagePullSecret) {
+		t.Fail()
+	}
+	// Other resources that do not belong to a docker compose service are kept.
+	if d.isToBeDeleted(&metav1.ObjectMeta{Name: "config"}) {
+		t.Fail()
+	}
+}
+
+var podsResource = schema.GroupVersionResource{
+	Version:  "v1",
+	Resource: "pods",
+}
+
+var servicesResource = schema.GroupVersionResource{
+	Version:  "v1",
+	Resource: "services",
+}
+
+func newTestResource(kind, name, service string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind(kind)
+	obj.SetNamespace("ns1")
+	obj.SetName(name)
+	obj.SetUID(types.UID(kind + "/" + name))
+	obj.SetLabels(map[string]string{
+		"env":                  "env1",
+		k8smeta.LabelCreatedAt: "1000",
+	})
+	if service != "" {
+		obj.SetAnnotations(map[string]string{
+			k8smeta.AnnotationName: service,
+		})
+	}
+	return obj
+}
+
+func newTestDownRunner(opts *Options, objects ...runtime.Object) *downRunner {
+	cfg := &config.Config{}
+	a := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	cfg.AddService(&dockerComposeConfig.Service{
+		Name: "b",
+	})
+	cfg.EnvironmentID = "env1"
+	cfg.EnvironmentLabel = "env"
+	cfg.Namespace = "ns1"
+	cfg.AddToFilter(a)
+	d := newDownRunner(cfg, opts)
+	d.dynamicClient = fake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+	d.discoverResources = func() ([]schema.GroupVersionResource, error) {
+		return []schema.GroupVersionResource{podsResource, servicesResource}, nil
+	}
+	return d
+}
+
+func listNames(t *testing.T, d *downRunner, gvr schema.GroupVersionResource) []string {
+	list, err := d.dynamicClient.Resource(gvr).Namespace("ns1").List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, item := range list.Items {
+		names = append(names, item.GetName())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestGetPhase(t *testing.T) {
+	if GetPhase("deployments") != phaseControllers || GetPhase("jobs") != phaseControllers {
+		t.Fail()
+	}
+	if GetPhase("pods") != phasePods {
+		t.Fail()
+	}
+	if GetPhase("services") != phaseOther || GetPhase("persistentvolumeclaims") != phaseOther || GetPhase("secrets") != phaseOther {
+		t.Fail()
+	}
+}
+
+func TestDownRunnerDeleteAll_Partial(t *testing.T) {
+	d := newTestDownRunner(&Options{},
+		newTestResource("Pod", "a-env1", "a"),
+		newTestResource("Pod", "b-env1", "b"),
+		newTestResource("Service", "a-env1", "a"),
+	)
+	deletions, err := d.deleteAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(listNames(t, d, podsResource), []string{"b-env1"}) {
+		t.Error(listNames(t, d, podsResource))
+	}
+	// Services are kept, because not all pods are deleted.
+	if !reflect.DeepEqual(listNames(t, d, servicesResource), []string{"a-env1"}) {
+		t.Error(listNames(t, d, servicesResource))
+	}
+	err = d.waitForDeletions(deletions)
+	if err != nil {
+		t.Error(err)
+	}
+}
+
+func TestDownRunnerDeleteAll_All(t *testing.T) {
+	d := newTestDownRunner(&Options{},
+		newTestResource("Pod", "a-env1", "a"),
+		newTestResource("Pod", "b-env1", "b"),
+		newTestResource("Service", "a-env1", "a"),
+	)
+	d.cfg.AddToFilter(d.cfg.Services["b"])
+	_, err := d.deleteAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listNames(t, d, podsResource)) != 0 || len(listNames(t, d, servicesResource)) != 0 {
+		t.Fail()
+	}
+}
+
+func TestDownRunnerDeleteControllersAndPods_DirectOnly(t *testing.T) {
+	d := newTestDownRunner(&Options{},
+		newTestResource("Pod", "a-env1", "a"),
+		newTestResource("Pod", "b-env1", "b"),
+	)
+	d.cfg.Services["a"].DockerComposeService.DependsOn = map[string]dockerComposeConfig.ServiceHealthiness{
+		"b": dockerComposeConfig.ServiceStarted,
+	}
+	d.cfg.ClearFilter()
+	d.cfg.AddToFilter(d.cfg.Services["a"])
+	d.directOnly = true
+	gvrs, err := d.discoverResources()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, deletedAll, err := d.deleteControllersAndPods(gvrs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The pod of the dependency b is kept, even though b matches the filter indirectly.
+	if deletedAll || !reflect.DeepEqual(listNames(t, d, podsResource), []string{"b-env1"}) {
+		t.Error(listNames(t, d, podsResource))
+	}
+}
+
+func TestDownRunnerDeleteAll_KeepsResourcesNotCreatedByUp(t *testing.T) {
+	configMapsResource := schema.GroupVersionResource{
+		Version:  "v1",
+		Resource: "configmaps",
+	}
+	secretsResource := schema.GroupVersionResource{
+		Version:  "v1",
+		Resource: "secrets",
+	}
+	// A resource that a user labelled with the environment.
+	userConfigMap := newTestResource("ConfigMap", "user-config", "")
+	userConfigMap.SetLabels(map[string]string{
+		"env": "env1",
+	})
+	d := newTestDownRunner(&Options{},
+		newTestResource("Pod", "a-env1", "a"),
+		newTestResource("ConfigMap", "other-config", ""),
+		newTestResource("Secret", "kube-compose-image-pull-env1", ""),
+		userConfigMap,
+	)
+	d.cfg.AddToFilter(d.cfg.Services["b"])
+	d.discoverResources = func() ([]schema.GroupVersionResource, error) {
+		return []schema.GroupVersionResource{podsResource, configMapsResource, secretsResource}, nil
+	}
+	_, err := d.deleteAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(listNames(t, d, podsResource)) != 0 || len(listNames(t, d, secretsResource)) != 0 {
+		t.Fail()
+	}
+	if !reflect.DeepEqual(listNames(t, d, configMapsResource), []string{"other-config", "user-config"}) {
+		t.Error(listNames(t, d, configMapsResource))
+	}
+}
+
+func TestDownRunnerDeleteAll_EphemeralNamespace(t *testing.T) {
+	namespace := &unstructured.Unstructured{}
+	namespace.SetAPIVersion("v1")
+	namespace.SetKind("Namespace")
+	namespace.SetName("ns1")
+	namespace.SetUID("ns1")
+	d := newTestDownRunner(&Options{}, namespace)
+	d.cfg.EphemeralNamespace = &config.EphemeralNamespace{}
+	d.cfg.AddToFilter(d.cfg.Services["b"])
+	deletions, err := d.deleteAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deletions) != 1 || deletions[0].deleted["ns1"] != "ns1" {
+		t.Error(deletions)
+	}
+	_, err = d.dynamicClient.Resource(NamespacesResource).Get("ns1", metav1.GetOptions{})
+	if !k8sError.IsNotFound(err) {
+		t.Error(err)
+	}
+}
+
+func TestDownRunnerWaitForDeletions_Timeout(t *testing.T) {
+	pod := newTestResource("Pod", "a-env1", "a")
+	d := newTestDownRunner(&Options{
+		Timeout: time.Millisecond,
+	}, pod)
+	// Simulate a pod that is terminating.
+	del := &deletion{
+		client:      d.dynamicClient.Resource(podsResource).Namespace("ns1"),
+		listOptions: d.newListOptions(),
+		resource:    "pods",
+		deleted: map[types.UID]string{
+			pod.GetUID(): pod.GetName(),
+		},
+	}
+	err := d.waitForDeletions([]*deletion{del})
+	if err == nil || err.Error() != "timed out waiting for pods to be deleted: a-env1" {
+		t.Error(err)
+	}
+}
+
+func TestDownRunnerDeleteAll_Orphans(t *testing.T) {
+	d := newTestDownRunner(&Options{},
+		newTestResource("Pod", "a-env1", "a"),
+		newTestResource("Pod", "b-env1", "b"),
+		newTestResource("Pod", "old-env1", "old"),
+		newTestResource("Service", "a-env1", "a"),
+	)
+	d.cfg.AddToFilter(d.cfg.Services["b"])
+	_, err := d.deleteAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(listNames(t, d, podsResource), []string{"old-env1"}) {
+		t.Error(listNames(t, d, podsResource))
+	}
+	// Services are kept, because the orphaned pod is not deleted.
+	if !reflect.DeepEqual(listNames(t, d, servicesResource), []string{"a-env1"}) {
+		t.Error(listNames(t, d, servicesResource))
+	}
+}
+
+func TestDownRunnerRemoveOrphans(t *testing.T) {
+	d := newTestDownRunner(&Options{
+		RemoveOrphans: true,
+	},
+		newTestResource("Pod", "a-env1", "a"),
+		newTestResource("Pod", "old-env1", "old"),
+		newTestResource("Service", "a-env1", "a"),
+		newTestResource("Service", "old-env1", "old"),
+	)
+	d.orphansOnly = true
+	for phase := phaseControllers; phase <= phaseOther; phase++ {
+		_, _, err := d.deletePhase([]schema.GroupVersionResource{podsResource, servicesResource}, phase)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !reflect.DeepEqual(listNames(t, d, podsResource), []string{"a-env1"}) {
+		t.Error(listNames(t, d, podsResource))
+	}
+	if !reflect.DeepEqual(listNames(t, d, servicesResource), []string{"a-env1"}) {
+		t.Error(listNames(t, d, servicesResource))
+	}
+}
@@ -0,0 +1,155 @@
+package down
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	log "github.com/Sirupsen/logrus"
+	dockerTypes "github.com/docker/docker/api/types"
+	dockerClient "github.com/docker/docker/client"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/registry"
+	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+)
+
+// The values of Options.RemoveImages.
+const (
+	// RemoveImagesLocal removes the images tagged and built by up from the local docker daemon.
+	RemoveImagesLocal = "local"
+	// RemoveImagesAll additionally deletes the images pushed by up from the docker registry, if the registry permits it.
+	RemoveImagesAll = "all"
+)
+
+// ImageRemover is the subset of the docker client used to remove local images.
+type ImageRemover interface {
+	ImageInspectWithRaw(ctx context.Context, image string) (dockerTypes.ImageInspect, []byte, error)
+	ImageRemove(ctx context.Context, image string, options dockerTypes.ImageRemoveOptions) ([]dockerTypes.ImageDelete, error)
+}
+
+// ValidateRemoveImages returns an error if the value is not one of the empty string, RemoveImagesLocal and RemoveImagesAll.
+func ValidateRemoveImages(removeImages string) error {
+	switch removeImages {
+	case "", RemoveImagesLocal, RemoveImagesAll:
+		return nil
+	}
+	return fmt.Errorf("invalid value %#v, must be one of %s and %s", removeImages, RemoveImagesLocal, RemoveImagesAll)
+}
+
+// getImageTags returns the tags of the images that up creates for each docker compose service.
+func getImageTags(cfg *config.Config) []string {
+	return []string{
+		cfg.EnvironmentID + "-main",
+		cfg.EnvironmentID + "-volumeinit",
+	}
+}
+
+// getComposeServicesWithImages returns the docker compose services whose images are to be removed, sorted by name.
+func (d *downRunner) getComposeServicesWithImages() []*config.Service {
+	var composeServices []*config.Service
+	for _, composeService := range d.cfg.Services {
+		if d.cfg.MatchesFilter(composeService) {
+			composeServices = append(composeServices, composeService)
+		}
+	}
+	sort.Slice(composeServices, func(i, j int) bool {
+		return composeServices[i].Name() < composeServices[j].Name()
+	})
+	return composeServices
+}
+
+// getLocalImageRefs returns the references of the images that up tags in the local docker daemon, like getAppImageEnsureCorrectPodImage
+// and getAppVolumeInitImage of package up.
+func (d *downRunner) getLocalImageRefs() ([]string, error) {
+	var imageRefs []string
+	for _, composeService := range d.getComposeServicesWithImages() {
+		for _, tag := range getImageTags(d.cfg) {
+			if d.cfg.ClusterImageStorage.DockerRegistry != nil {
+				imageRef, err := registry.GetPushImage(d.cfg, composeService, tag)
+				if err != nil {
+					return nil, err
+				}
+				imageRefs = append(imageRefs, imageRef)
+			} else {
+				imageRefs = append(imageRefs, fmt.Sprintf("%s/%s/%s:%s", docker.DefaultDomain, docker.OfficialRepoName,
+					composeService.NameEscaped, tag))
+			}
+		}
+	}
+	return imageRefs, nil
+}
+
+// removeLocalImages removes the tags created by up. Volume init images are built by up and only have these tags, so they are removed as
+// well. Images that are still in use are kept.
+func (d *downRunner) removeLocalImages(ctx context.Context, imageRemover ImageRemover) error {
+	imageRefs, err := d.getLocalImageRefs()
+	if err != nil {
+		return err
+	}
+	for _, imageRef := range imageRefs {
+		_, _, err := imageRemover.ImageInspectWithRaw(ctx, imageRef)
+		if dockerClient.IsErrNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		_, err = imageRemover.ImageRemove(ctx, imageRef, dockerTypes.ImageRemoveOptions{
+			PruneChildren: true,
+		})
+		if err != nil {
+			log.Warnf("could not remove image %s: %v", imageRef, err)
+			continue
+		}
+		log.Infof("removed image %s\n", imageRef)
+	}
+	return nil
+}
+
+// removeRegistryImages deletes the images pushed by up from the docker registry. Repositories whose manifests cannot be deleted are
+// skipped, since the registry may only deny deleting manifests of some repositories.
+func (d *downRunner) removeRegistryImages(ctx context.Context, registryClient *docker.Registry) error {
+	warned := false
+	for _, composeService := range d.getComposeServicesWithImages() {
+		repository, err := registry.GetRepository(d.cfg, composeService)
+		if err != nil {
+			return err
+		}
+		for _, tag := range getImageTags(d.cfg) {
+			deleted, err := registryClient.DeleteTag(ctx, repository, tag)
+			if _, ok := err.(*docker.ErrorDeleteNotPermitted); ok {
+				if warned {
+					log.Debug(err)
+				} else {
+					log.Warn(err)
+					warned = true
+				}
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if deleted {
+				log.Infof("deleted image %s:%s from the docker registry\n", repository, tag)
+			}
+		}
+	}
+	return nil
+}
+
+func (d *downRunner) removeImages() error {
+	ctx := context.Background()
+	dc, err := dockerClient.NewEnvClient()
+	if err != nil {
+		return err
+	}
+	err = d.removeLocalImages(ctx, dc)
+	if err != nil || d.opts.RemoveImages != RemoveImagesAll || d.cfg.ClusterImageStorage.DockerRegistry == nil {
+		return err
+	}
+	registryClient, err := registry.NewClient(d.cfg)
+	if err != nil {
+		return err
+	}
+	return d.removeRegistryImages(ctx, registryClient)
+}
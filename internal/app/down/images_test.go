@@ -0,0 +1,166 @@
+package down
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"reflect"
+	"testing"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+)
+
+type testImageNotFoundError struct {
+}
+
+func (err *testImageNotFoundError) Error() string {
+	return "Error: No such image"
+}
+
+func (err *testImageNotFoundError) NotFound() bool {
+	return true
+}
+
+type testImageRemover struct {
+	images  map[string]bool
+	inUse   map[string]bool
+	removed []string
+}
+
+func (r *testImageRemover) ImageInspectWithRaw(ctx context.Context, image string) (dockerTypes.ImageInspect, []byte, error) {
+	if !r.images[image] {
+		return dockerTypes.ImageInspect{}, nil, &testImageNotFoundError{}
+	}
+	return dockerTypes.ImageInspect{}, nil, nil
+}
+
+func (r *testImageRemover) ImageRemove(ctx context.Context, image string, options dockerTypes.ImageRemoveOptions) (
+	[]dockerTypes.ImageDelete, error) {
+	if r.inUse[image] {
+		return nil, fmt.Errorf("conflict: image %s is in use", image)
+	}
+	r.removed = append(r.removed, image)
+	return nil, nil
+}
+
+func TestValidateRemoveImages(t *testing.T) {
+	for _, removeImages := range []string{"", "local", "all"} {
+		if ValidateRemoveImages(removeImages) != nil {
+			t.Error(removeImages)
+		}
+	}
+	if ValidateRemoveImages("none") == nil {
+		t.Fail()
+	}
+}
+
+func TestDownRunnerGetLocalImageRefs_Docker(t *testing.T) {
+	d := newTestDownRunner(&Options{})
+	expected := []string{
+		"docker.io/library/a:env1-main",
+		"docker.io/library/a:env1-volumeinit",
+	}
+	if actual, err := d.getLocalImageRefs(); err != nil || !reflect.DeepEqual(actual, expected) {
+		t.Error(actual, err)
+	}
+}
+
+func TestDownRunnerGetLocalImageRefs_DockerRegistry(t *testing.T) {
+	d := newTestDownRunner(&Options{})
+	d.cfg.AddToFilter(d.cfg.Services["b"])
+	d.cfg.ClusterImageStorage.DockerRegistry = &config.DockerRegistryClusterImageStorage{
+		Host: "my-registry.example.com",
+	}
+	expected := []string{
+		"my-registry.example.com/ns1/a:env1-main",
+		"my-registry.example.com/ns1/a:env1-volumeinit",
+		"my-registry.example.com/ns1/b:env1-main",
+		"my-registry.example.com/ns1/b:env1-volumeinit",
+	}
+	if actual, err := d.getLocalImageRefs(); err != nil || !reflect.DeepEqual(actual, expected) {
+		t.Error(actual, err)
+	}
+}
+
+func TestDownRunnerGetLocalImageRefs_RepositoryTemplate(t *testing.T) {
+	d := newTestDownRunner(&Options{})
+	d.cfg.ClusterImageStorage.DockerRegistry = &config.DockerRegistryClusterImageStorage{
+		Host:       "my-registry.example.com",
+		Repository: "team/{{.EnvironmentID}}-{{.Service}}",
+	}
+	expected := []string{
+		"my-registry.example.com/team/env1-a:env1-main",
+		"my-registry.example.com/team/env1-a:env1-volumeinit",
+	}
+	if actual, err := d.getLocalImageRefs(); err != nil || !reflect.DeepEqual(actual, expected) {
+		t.Error(actual, err)
+	}
+}
+
+func TestDownRunnerRemoveLocalImages(t *testing.T) {
+	d := newTestDownRunner(&Options{})
+	d.cfg.AddToFilter(d.cfg.Services["b"])
+	imageRemover := &testImageRemover{
+		images: map[string]bool{
+			"docker.io/library/a:env1-main":       true,
+			"docker.io/library/a:env1-volumeinit": true,
+			"docker.io/library/b:env1-main":       true,
+		},
+		inUse: map[string]bool{
+			"docker.io/library/b:env1-main": true,
+		},
+	}
+	err := d.removeLocalImages(context.Background(), imageRemover)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"docker.io/library/a:env1-main",
+		"docker.io/library/a:env1-volumeinit",
+	}
+	if !reflect.DeepEqual(imageRemover.removed, expected) {
+		t.Error(imageRemover.removed)
+	}
+}
+
+func TestDownRunnerRemoveRegistryImages_NotPermitted(t *testing.T) {
+	d := newTestDownRunner(&Options{})
+	d.cfg.AddToFilter(d.cfg.Services["b"])
+	d.cfg.ClusterImageStorage.DockerRegistry = &config.DockerRegistryClusterImageStorage{}
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write([]byte(`{"tags":["env1-main","env1-volumeinit"]}`))
+		case http.MethodHead:
+			// Each tag references a different manifest.
+			w.Header().Set("Docker-Content-Digest", "sha256:"+path.Base(r.URL.Path))
+		case http.MethodDelete:
+			deleted = append(deleted, r.URL.Path)
+			if path.Dir(r.URL.Path) == "/v2/ns1/a/manifests" {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer server.Close()
+	err := d.removeRegistryImages(context.Background(), &docker.Registry{
+		URL: server.URL,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"/v2/ns1/a/manifests/sha256:env1-main",
+		"/v2/ns1/b/manifests/sha256:env1-main",
+		"/v2/ns1/b/manifests/sha256:env1-volumeinit",
+	}
+	if !reflect.DeepEqual(deleted, expected) {
+		t.Error(deleted)
+	}
+}
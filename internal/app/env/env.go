@@ -0,0 +1,136 @@
+package env
+
+import (
+	"sort"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/app/podstatus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// Environment summarizes the resources of an environment in a namespace.
+type Environment struct {
+	ID string `json:"id"`
+	// The number of docker compose services that have a pod or a Kubernetes Service.
+	Services int `json:"services"`
+	// The number of docker compose services that have at least one ready pod.
+	Ready int `json:"ready"`
+	// The time at which the oldest resource of the environment was created.
+	CreatedAt time.Time `json:"createdAt"`
+	// The user that created the environment in the form user@host, or the empty string if unknown.
+	CreatedBy string `json:"createdBy"`
+}
+
+type environmentBuilder struct {
+	env      *Environment
+	services map[string]bool
+	ready    map[string]bool
+}
+
+type listRunner struct {
+	builders         map[string]*environmentBuilder
+	cfg              *config.Config
+	k8sClientset     *kubernetes.Clientset
+	k8sPodClient     clientV1.PodInterface
+	k8sServiceClient clientV1.ServiceInterface
+}
+
+func (l *listRunner) initKubernetesClientset() error {
+	k8sClientset, err := kubernetes.NewForConfig(l.cfg.KubeConfig)
+	if err != nil {
+		return err
+	}
+	l.k8sClientset = k8sClientset
+	l.k8sPodClient = l.k8sClientset.CoreV1().Pods(l.cfg.Namespace)
+	l.k8sServiceClient = l.k8sClientset.CoreV1().Services(l.cfg.Namespace)
+	return nil
+}
+
+// add adds a resource to its environment, and returns the docker compose service of the resource. Resources that were not created by kube
+// compose are ignored, in which case the empty string is returned.
+func (l *listRunner) add(objectMeta *metav1.ObjectMeta) (*environmentBuilder, string) {
+	composeService := objectMeta.Annotations[k8smeta.AnnotationName]
+	if composeService == "" {
+		return nil, ""
+	}
+	envID := objectMeta.Labels[l.cfg.EnvironmentLabel]
+	b := l.builders[envID]
+	if b == nil {
+		b = &environmentBuilder{
+			env: &Environment{
+				ID:        envID,
+				CreatedAt: objectMeta.CreationTimestamp.Time,
+			},
+			services: map[string]bool{},
+			ready:    map[string]bool{},
+		}
+		l.builders[envID] = b
+	}
+	b.services[composeService] = true
+	if objectMeta.CreationTimestamp.Time.Before(b.env.CreatedAt) {
+		b.env.CreatedAt = objectMeta.CreationTimestamp.Time
+	}
+	if b.env.CreatedBy == "" {
+		b.env.CreatedBy = objectMeta.Annotations[k8smeta.AnnotationCreatedBy]
+	}
+	return b, composeService
+}
+
+func (l *listRunner) addPod(pod *v1.Pod) {
+	b, composeService := l.add(&pod.ObjectMeta)
+	if b != nil && podstatus.IsPodReady(pod) {
+		b.ready[composeService] = true
+	}
+}
+
+func (l *listRunner) result() []*Environment {
+	environments := make([]*Environment, 0, len(l.builders))
+	for _, b := range l.builders {
+		b.env.Services = len(b.services)
+		b.env.Ready = len(b.ready)
+		environments = append(environments, b.env)
+	}
+	sort.Slice(environments, func(i, j int) bool {
+		return environments[i].ID < environments[j].ID
+	})
+	return environments
+}
+
+func (l *listRunner) run() ([]*Environment, error) {
+	err := l.initKubernetesClientset()
+	if err != nil {
+		return nil, err
+	}
+	listOptions := metav1.ListOptions{
+		LabelSelector: l.cfg.EnvironmentLabel,
+	}
+	podList, err := l.k8sPodClient.List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(podList.Items); i++ {
+		l.addPod(&podList.Items[i])
+	}
+	serviceList, err := l.k8sServiceClient.List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < len(serviceList.Items); i++ {
+		l.add(&serviceList.Items[i].ObjectMeta)
+	}
+	return l.result(), nil
+}
+
+// List returns the environments in the namespace of cfg, ordered by ID.
+func List(cfg *config.Config) ([]*Environment, error) {
+	l := &listRunner{
+		builders: map[string]*environmentBuilder{},
+		cfg:      cfg,
+	}
+	return l.run()
+}
@@ -0,0 +1,63 @@
+package env
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newTestPod(composeService, envID string, createdAt int64, ready bool) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				k8smeta.AnnotationName:      composeService,
+				k8smeta.AnnotationCreatedBy: "user@host",
+			},
+			CreationTimestamp: metav1.NewTime(time.Unix(createdAt, 0)),
+			Labels: map[string]string{
+				"env": envID,
+			},
+		},
+	}
+	if ready {
+		pod.Status.Conditions = []v1.PodCondition{
+			{
+				Type:   v1.PodReady,
+				Status: v1.ConditionTrue,
+			},
+		}
+	}
+	return pod
+}
+
+func TestListRunnerResult(t *testing.T) {
+	l := &listRunner{
+		builders: map[string]*environmentBuilder{},
+		cfg: &config.Config{
+			EnvironmentLabel: "env",
+		},
+	}
+	l.addPod(newTestPod("a", "env2", 2000, true))
+	l.addPod(newTestPod("b", "env2", 1000, false))
+	l.addPod(newTestPod("a", "env1", 3000, true))
+	l.addPod(newTestPod("a", "env1", 3000, false))
+	unmanaged := newTestPod("a", "env3", 1000, true)
+	delete(unmanaged.ObjectMeta.Annotations, k8smeta.AnnotationName)
+	l.addPod(unmanaged)
+	environments := l.result()
+	if len(environments) != 2 {
+		t.Fatal(environments)
+	}
+	env1 := environments[0]
+	if env1.ID != "env1" || env1.Services != 1 || env1.Ready != 1 || env1.CreatedBy != "user@host" {
+		t.Error(env1)
+	}
+	env2 := environments[1]
+	if env2.ID != "env2" || env2.Services != 2 || env2.Ready != 1 || env2.CreatedAt.Unix() != 1000 {
+		t.Error(env2)
+	}
+}
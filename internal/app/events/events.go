@@ -0,0 +1,381 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/app/podstatus"
+	v1 "k8s.io/api/core/v1"
+	k8sMeta "k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	k8swatch "k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// The types of events.
+const (
+	TypeCreated     = "created"
+	TypeImagePulled = "image-pulled"
+	TypeStarted     = "started"
+	TypeReady       = "ready"
+	TypeExited      = "exited"
+	TypeDeleted     = "deleted"
+	TypeWarning     = "warning"
+)
+
+// watchRetryInterval is the time to wait before watching again if a watch fails.
+var watchRetryInterval = 2 * time.Second
+
+var timeNow = time.Now
+
+// Event is a lifecycle event of a docker compose service.
+type Event struct {
+	Time    time.Time `json:"time"`
+	Service string    `json:"service"`
+	Type    string    `json:"type"`
+	// The Kubernetes resource that the event is about, in the form kind/name (e.g. pod/web-myenv).
+	Object string `json:"object"`
+	// The exit code of the container, if the type is TypeExited.
+	ExitCode *int32 `json:"exitCode,omitempty"`
+	Message  string `json:"message,omitempty"`
+}
+
+// Options are the options of the events command.
+type Options struct {
+	// True to write events as JSON objects, one per line.
+	JSON bool
+	Out  io.Writer
+}
+
+// FormatEvent formats an event as a single line of text.
+func FormatEvent(e *Event) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s %s %s %s", e.Time.UTC().Format(time.RFC3339), e.Service, e.Type, e.Object)
+	if e.ExitCode != nil {
+		fmt.Fprintf(&sb, " (exit code %d)", *e.ExitCode)
+	}
+	if e.Message != "" {
+		fmt.Fprintf(&sb, ": %s", e.Message)
+	}
+	return sb.String()
+}
+
+// podState records which events have been emitted for a pod, so that each event is emitted once.
+type podState struct {
+	composeService *config.Service
+	uid            types.UID
+	started        bool
+	ready          bool
+	// The keys are container names and restart counts of terminated containers.
+	exited map[string]bool
+}
+
+type eventsRunner struct {
+	cfg          *config.Config
+	k8sClientset *kubernetes.Clientset
+	opts         *Options
+	pods         map[string]*podState
+	services     map[string]bool
+	// The keys are UIDs and counts of Kubernetes Events, so that Kubernetes Events are not emitted twice when watching again.
+	k8sEvents map[string]bool
+}
+
+func (r *eventsRunner) initKubernetesClientset() error {
+	k8sClientset, err := kubernetes.NewForConfig(r.cfg.KubeConfig)
+	if err != nil {
+		return err
+	}
+	r.k8sClientset = k8sClientset
+	return nil
+}
+
+func (r *eventsRunner) emit(e *Event) {
+	if r.opts.JSON {
+		data, err := json.Marshal(e)
+		if err != nil {
+			log.Error(err)
+			return
+		}
+		fmt.Fprintf(r.opts.Out, "%s\n", data)
+		return
+	}
+	fmt.Fprintln(r.opts.Out, FormatEvent(e))
+}
+
+func (r *eventsRunner) findComposeService(objectMeta *metav1.ObjectMeta) *config.Service {
+	composeService := k8smeta.FindFromObjectMeta(r.cfg, objectMeta)
+	if composeService == nil || !r.cfg.MatchesFilterDirectly(composeService) || k8smeta.IsOneOff(objectMeta) {
+		return nil
+	}
+	return composeService
+}
+
+func (r *eventsRunner) podEvents(eventType k8swatch.EventType, pod *v1.Pod) []*Event {
+	name := pod.ObjectMeta.Name
+	object := "pod/" + name
+	if eventType == k8swatch.Deleted {
+		state := r.pods[name]
+		if state == nil {
+			return nil
+		}
+		delete(r.pods, name)
+		return []*Event{
+			{Time: timeNow(), Service: state.composeService.Name(), Type: TypeDeleted, Object: object},
+		}
+	}
+	var events []*Event
+	state := r.pods[name]
+	if state == nil {
+		composeService := r.findComposeService(&pod.ObjectMeta)
+		if composeService == nil {
+			return nil
+		}
+		state = &podState{
+			composeService: composeService,
+			uid:            pod.ObjectMeta.UID,
+			exited:         map[string]bool{},
+		}
+		r.pods[name] = state
+		events = append(events, &Event{Time: pod.ObjectMeta.CreationTimestamp.Time, Type: TypeCreated})
+	}
+	events = append(events, r.containerEvents(state, pod)...)
+	if !state.started {
+		if s, _ := podstatus.Parse(pod); s >= podstatus.StatusStarted {
+			state.started = true
+			events = append(events, &Event{Time: getStartedTime(pod), Type: TypeStarted})
+		}
+	}
+	if !state.ready && podstatus.IsPodReady(pod) {
+		state.ready = true
+		events = append(events, &Event{Time: getReadyTime(pod), Type: TypeReady})
+	}
+	for _, e := range events {
+		e.Service = state.composeService.Name()
+		e.Object = object
+	}
+	return events
+}
+
+func (r *eventsRunner) containerEvents(state *podState, pod *v1.Pod) []*Event {
+	var events []*Event
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		// The last termination state is checked too, because a container may have been restarted before the pod was observed.
+		if t := containerStatus.LastTerminationState.Terminated; t != nil && containerStatus.RestartCount > 0 {
+			events = appendExitedEvent(events, state, containerStatus.Name, containerStatus.RestartCount-1, t)
+		}
+		if t := containerStatus.State.Terminated; t != nil {
+			events = appendExitedEvent(events, state, containerStatus.Name, containerStatus.RestartCount, t)
+		}
+	}
+	return events
+}
+
+func appendExitedEvent(events []*Event, state *podState, containerName string, restartCount int32,
+	t *v1.ContainerStateTerminated) []*Event {
+	key := fmt.Sprintf("%s/%d", containerName, restartCount)
+	if state.exited[key] {
+		return events
+	}
+	state.exited[key] = true
+	exitCode := t.ExitCode
+	e := &Event{
+		Time:     t.FinishedAt.Time,
+		Type:     TypeExited,
+		ExitCode: &exitCode,
+		Message:  t.Reason,
+	}
+	if e.Time.IsZero() {
+		e.Time = timeNow()
+	}
+	return append(events, e)
+}
+
+// getStartedTime returns the time at which the last container of the pod started running.
+func getStartedTime(pod *v1.Pod) time.Time {
+	var startedAt time.Time
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		if r := containerStatus.State.Running; r != nil && r.StartedAt.Time.After(startedAt) {
+			startedAt = r.StartedAt.Time
+		}
+	}
+	if startedAt.IsZero() {
+		return timeNow()
+	}
+	return startedAt
+}
+
+func getReadyTime(pod *v1.Pod) time.Time {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady && !condition.LastTransitionTime.IsZero() {
+			return condition.LastTransitionTime.Time
+		}
+	}
+	return timeNow()
+}
+
+func (r *eventsRunner) serviceEvents(eventType k8swatch.EventType, service *v1.Service) []*Event {
+	name := service.ObjectMeta.Name
+	composeService := r.findComposeService(&service.ObjectMeta)
+	if composeService == nil {
+		return nil
+	}
+	e := &Event{
+		Service: composeService.Name(),
+		Object:  "service/" + name,
+	}
+	switch {
+	case eventType == k8swatch.Deleted:
+		delete(r.services, name)
+		e.Time = timeNow()
+		e.Type = TypeDeleted
+	case !r.services[name]:
+		r.services[name] = true
+		e.Time = service.ObjectMeta.CreationTimestamp.Time
+		e.Type = TypeCreated
+	default:
+		return nil
+	}
+	return []*Event{e}
+}
+
+// k8sEventEvents translates Kubernetes Events about pods of the environment. Kubernetes Events do not have the labels of the objects they
+// are about, so they are mapped to docker compose services through the pods that have been observed.
+func (r *eventsRunner) k8sEventEvents(eventType k8swatch.EventType, k8sEvent *v1.Event) []*Event {
+	if eventType == k8swatch.Deleted || k8sEvent.InvolvedObject.Kind != "Pod" {
+		return nil
+	}
+	state := r.pods[k8sEvent.InvolvedObject.Name]
+	// Pods are recreated with the same name, so the UID is checked to ignore Kubernetes Events about previous pods.
+	if state == nil || state.uid != k8sEvent.InvolvedObject.UID {
+		return nil
+	}
+	key := fmt.Sprintf("%s/%d", k8sEvent.ObjectMeta.UID, k8sEvent.Count)
+	if r.k8sEvents[key] {
+		return nil
+	}
+	r.k8sEvents[key] = true
+	e := &Event{
+		Time:    getK8sEventTime(k8sEvent),
+		Service: state.composeService.Name(),
+		Object:  "pod/" + k8sEvent.InvolvedObject.Name,
+		Message: k8sEvent.Message,
+	}
+	switch {
+	case k8sEvent.Reason == "Pulled":
+		e.Type = TypeImagePulled
+	case k8sEvent.Type == v1.EventTypeWarning:
+		e.Type = TypeWarning
+	default:
+		return nil
+	}
+	return []*Event{e}
+}
+
+func getK8sEventTime(k8sEvent *v1.Event) time.Time {
+	switch {
+	case !k8sEvent.LastTimestamp.IsZero():
+		return k8sEvent.LastTimestamp.Time
+	case !k8sEvent.EventTime.IsZero():
+		return k8sEvent.EventTime.Time
+	case !k8sEvent.FirstTimestamp.IsZero():
+		return k8sEvent.FirstTimestamp.Time
+	}
+	return timeNow()
+}
+
+func (r *eventsRunner) translate(event k8swatch.Event) []*Event {
+	switch obj := event.Object.(type) {
+	case *v1.Pod:
+		return r.podEvents(event.Type, obj)
+	case *v1.Service:
+		return r.serviceEvents(event.Type, obj)
+	case *v1.Event:
+		return r.k8sEventEvents(event.Type, obj)
+	}
+	return nil
+}
+
+// watchForever watches resources of a kind and sends their events to the channel. The watch is restarted whenever it is closed by the
+// server or fails, continuing from the last observed resource version if possible.
+func watchForever(kind string, listOptions metav1.ListOptions, watchFunc func(metav1.ListOptions) (k8swatch.Interface, error),
+	ch chan<- k8swatch.Event) {
+	for {
+		watch, err := watchFunc(listOptions)
+		if err != nil {
+			log.Warnf("error watching %s: %v", kind, err)
+			time.Sleep(watchRetryInterval)
+			continue
+		}
+		for event := range watch.ResultChan() {
+			if event.Type == k8swatch.Error {
+				// Typically the resource version is too old, so watch again from the current state.
+				log.Debugf("error event while watching %s: %+v", kind, event.Object)
+				listOptions.ResourceVersion = ""
+				break
+			}
+			if accessor, err := k8sMeta.Accessor(event.Object); err == nil {
+				listOptions.ResourceVersion = accessor.GetResourceVersion()
+			}
+			ch <- event
+		}
+		watch.Stop()
+	}
+}
+
+func (r *eventsRunner) run() error {
+	err := r.initKubernetesClientset()
+	if err != nil {
+		return err
+	}
+	core := r.k8sClientset.CoreV1()
+	labelSelector := r.cfg.EnvironmentLabel + "=" + r.cfg.EnvironmentID
+	ch := make(chan k8swatch.Event)
+	// Pods are watched first, so that Kubernetes Events about pods that exist when the command starts can be mapped to docker compose
+	// services.
+	podList, err := core.Pods(r.cfg.Namespace).List(metav1.ListOptions{
+		LabelSelector: labelSelector,
+	})
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(podList.Items); i++ {
+		for _, e := range r.podEvents(k8swatch.Added, &podList.Items[i]) {
+			r.emit(e)
+		}
+	}
+	go watchForever("pods", metav1.ListOptions{
+		LabelSelector:   labelSelector,
+		ResourceVersion: podList.ResourceVersion,
+	}, core.Pods(r.cfg.Namespace).Watch, ch)
+	go watchForever("services", metav1.ListOptions{
+		LabelSelector: labelSelector,
+	}, core.Services(r.cfg.Namespace).Watch, ch)
+	go watchForever("events", metav1.ListOptions{}, core.Events(r.cfg.Namespace).Watch, ch)
+	for event := range ch {
+		for _, e := range r.translate(event) {
+			r.emit(e)
+		}
+	}
+	return nil
+}
+
+// Run writes lifecycle events of the docker compose services that match the filter of cfg directly, translated from pods, Kubernetes
+// Services and Kubernetes Events. Events of resources that exist when Run is called are written first. Run does not return unless an error
+// occurs.
+func Run(cfg *config.Config, opts *Options) error {
+	r := &eventsRunner{
+		cfg:       cfg,
+		opts:      opts,
+		pods:      map[string]*podState{},
+		services:  map[string]bool{},
+		k8sEvents: map[string]bool{},
+	}
+	return r.run()
+}
@@ -0,0 +1,273 @@
+package events
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8swatch "k8s.io/apimachinery/pkg/watch"
+)
+
+var testTime = time.Date(2019, 7, 1, 12, 0, 0, 0, time.UTC)
+
+func withMockTimeNow(cb func()) {
+	orig := timeNow
+	defer func() {
+		timeNow = orig
+	}()
+	timeNow = func() time.Time {
+		return testTime
+	}
+	cb()
+}
+
+func newTestEventsRunner(out *bytes.Buffer) *eventsRunner {
+	cfg := &config.Config{}
+	a := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	cfg.AddService(&dockerComposeConfig.Service{
+		Name: "b",
+	})
+	cfg.AddToFilter(a)
+	return &eventsRunner{
+		cfg: cfg,
+		opts: &Options{
+			Out: out,
+		},
+		pods:      map[string]*podState{},
+		services:  map[string]bool{},
+		k8sEvents: map[string]bool{},
+	}
+}
+
+func newTestObjectMeta(service string) metav1.ObjectMeta {
+	return metav1.ObjectMeta{
+		Name: service + "-env1",
+		UID:  "uid1",
+		Annotations: map[string]string{
+			k8smeta.AnnotationName: service,
+		},
+		CreationTimestamp: metav1.NewTime(testTime),
+	}
+}
+
+func newTestPod(service string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: newTestObjectMeta(service),
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name: service,
+					State: v1.ContainerState{
+						Waiting: &v1.ContainerStateWaiting{},
+					},
+				},
+			},
+		},
+	}
+}
+
+func eventTypes(events []*Event) []string {
+	var types []string
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func assertEventTypes(t *testing.T, events []*Event, expected ...string) {
+	actual := eventTypes(events)
+	if len(actual) != len(expected) {
+		t.Fatal(actual)
+	}
+	for i := range actual {
+		if actual[i] != expected[i] {
+			t.Fatal(actual)
+		}
+	}
+}
+
+func TestFormatEvent(t *testing.T) {
+	exitCode := int32(2)
+	s := FormatEvent(&Event{
+		Time:     testTime,
+		Service:  "a",
+		Type:     TypeExited,
+		Object:   "pod/a-env1",
+		ExitCode: &exitCode,
+		Message:  "Error",
+	})
+	if s != "2019-07-01T12:00:00Z a exited pod/a-env1 (exit code 2): Error" {
+		t.Error(s)
+	}
+}
+
+func TestEventsRunnerEmit_JSON(t *testing.T) {
+	var out bytes.Buffer
+	r := newTestEventsRunner(&out)
+	r.opts.JSON = true
+	r.emit(&Event{
+		Time:    testTime,
+		Service: "a",
+		Type:    TypeReady,
+		Object:  "pod/a-env1",
+	})
+	expected := `{"time":"2019-07-01T12:00:00Z","service":"a","type":"ready","object":"pod/a-env1"}` + "\n"
+	if out.String() != expected {
+		t.Error(out.String())
+	}
+}
+
+func TestEventsRunnerPodEvents_Lifecycle(t *testing.T) {
+	withMockTimeNow(func() {
+		r := newTestEventsRunner(nil)
+		pod := newTestPod("a")
+		assertEventTypes(t, r.podEvents(k8swatch.Added, pod), TypeCreated)
+		assertEventTypes(t, r.podEvents(k8swatch.Modified, pod))
+
+		pod.Status.ContainerStatuses[0].State = v1.ContainerState{
+			Running: &v1.ContainerStateRunning{},
+		}
+		pod.Status.Conditions = []v1.PodCondition{
+			{Type: v1.PodReady, Status: v1.ConditionTrue},
+		}
+		events := r.podEvents(k8swatch.Modified, pod)
+		assertEventTypes(t, events, TypeStarted, TypeReady)
+		if events[0].Service != "a" || events[0].Object != "pod/a-env1" {
+			t.Error(events[0])
+		}
+		assertEventTypes(t, r.podEvents(k8swatch.Modified, pod))
+
+		pod.Status.ContainerStatuses[0].State = v1.ContainerState{
+			Terminated: &v1.ContainerStateTerminated{
+				ExitCode: 3,
+				Reason:   "Error",
+			},
+		}
+		events = r.podEvents(k8swatch.Modified, pod)
+		assertEventTypes(t, events, TypeExited)
+		if *events[0].ExitCode != 3 || events[0].Message != "Error" {
+			t.Error(events[0])
+		}
+		assertEventTypes(t, r.podEvents(k8swatch.Modified, pod))
+		assertEventTypes(t, r.podEvents(k8swatch.Deleted, pod), TypeDeleted)
+		if len(r.pods) != 0 {
+			t.Fail()
+		}
+	})
+}
+
+func TestEventsRunnerPodEvents_Pending(t *testing.T) {
+	withMockTimeNow(func() {
+		r := newTestEventsRunner(nil)
+		pod := newTestPod("a")
+		pod.Status.Phase = v1.PodPending
+		pod.Status.ContainerStatuses = nil
+		assertEventTypes(t, r.podEvents(k8swatch.Added, pod), TypeCreated)
+		assertEventTypes(t, r.podEvents(k8swatch.Modified, pod))
+	})
+}
+
+func TestEventsRunnerPodEvents_StartedTime(t *testing.T) {
+	withMockTimeNow(func() {
+		r := newTestEventsRunner(nil)
+		pod := newTestPod("a")
+		startedAt := testTime.Add(-time.Minute)
+		pod.Status.ContainerStatuses[0].State = v1.ContainerState{
+			Running: &v1.ContainerStateRunning{
+				StartedAt: metav1.NewTime(startedAt),
+			},
+		}
+		events := r.podEvents(k8swatch.Added, pod)
+		assertEventTypes(t, events, TypeCreated, TypeStarted)
+		if !events[1].Time.Equal(startedAt) {
+			t.Error(events[1].Time)
+		}
+	})
+}
+
+func TestEventsRunnerPodEvents_Restarted(t *testing.T) {
+	r := newTestEventsRunner(nil)
+	pod := newTestPod("a")
+	pod.Status.ContainerStatuses[0].RestartCount = 1
+	pod.Status.ContainerStatuses[0].LastTerminationState = v1.ContainerState{
+		Terminated: &v1.ContainerStateTerminated{
+			ExitCode: 1,
+		},
+	}
+	assertEventTypes(t, r.podEvents(k8swatch.Added, pod), TypeCreated, TypeExited)
+}
+
+func TestEventsRunnerPodEvents_FilteredOut(t *testing.T) {
+	r := newTestEventsRunner(nil)
+	assertEventTypes(t, r.podEvents(k8swatch.Added, newTestPod("b")))
+	pod := newTestPod("a")
+	pod.ObjectMeta.Annotations[k8smeta.AnnotationOneOff] = "true"
+	assertEventTypes(t, r.podEvents(k8swatch.Added, pod))
+}
+
+func TestEventsRunnerServiceEvents(t *testing.T) {
+	r := newTestEventsRunner(nil)
+	service := &v1.Service{
+		ObjectMeta: newTestObjectMeta("a"),
+	}
+	events := r.serviceEvents(k8swatch.Added, service)
+	assertEventTypes(t, events, TypeCreated)
+	if events[0].Object != "service/a-env1" || !events[0].Time.Equal(testTime) {
+		t.Error(events[0])
+	}
+	assertEventTypes(t, r.serviceEvents(k8swatch.Modified, service))
+	assertEventTypes(t, r.serviceEvents(k8swatch.Deleted, service), TypeDeleted)
+}
+
+func newTestK8sEvent(reason, eventType string) *v1.Event {
+	return &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			UID: "event1",
+		},
+		InvolvedObject: v1.ObjectReference{
+			Kind: "Pod",
+			Name: "a-env1",
+			UID:  "uid1",
+		},
+		Reason:        reason,
+		Message:       "message1",
+		Type:          eventType,
+		Count:         1,
+		LastTimestamp: metav1.NewTime(testTime),
+	}
+}
+
+func TestEventsRunnerK8sEventEvents_Pulled(t *testing.T) {
+	r := newTestEventsRunner(nil)
+	r.podEvents(k8swatch.Added, newTestPod("a"))
+	k8sEvent := newTestK8sEvent("Pulled", v1.EventTypeNormal)
+	events := r.k8sEventEvents(k8swatch.Added, k8sEvent)
+	assertEventTypes(t, events, TypeImagePulled)
+	if events[0].Service != "a" || events[0].Message != "message1" || !events[0].Time.Equal(testTime) {
+		t.Error(events[0])
+	}
+	// Kubernetes Events are emitted once, even when watching again.
+	assertEventTypes(t, r.k8sEventEvents(k8swatch.Added, k8sEvent))
+}
+
+func TestEventsRunnerK8sEventEvents_Warning(t *testing.T) {
+	r := newTestEventsRunner(nil)
+	r.podEvents(k8swatch.Added, newTestPod("a"))
+	assertEventTypes(t, r.k8sEventEvents(k8swatch.Added, newTestK8sEvent("BackOff", v1.EventTypeWarning)), TypeWarning)
+	assertEventTypes(t, r.k8sEventEvents(k8swatch.Added, newTestK8sEvent("Scheduled", v1.EventTypeNormal)))
+}
+
+func TestEventsRunnerK8sEventEvents_PreviousPod(t *testing.T) {
+	r := newTestEventsRunner(nil)
+	r.podEvents(k8swatch.Added, newTestPod("a"))
+	k8sEvent := newTestK8sEvent("Pulled", v1.EventTypeNormal)
+	k8sEvent.InvolvedObject.UID = "uid0"
+	assertEventTypes(t, r.k8sEventEvents(k8swatch.Added, k8sEvent))
+}
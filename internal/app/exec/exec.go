@@ -0,0 +1,174 @@
+package exec
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"golang.org/x/crypto/ssh/terminal"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/remotecommand"
+	utilExec "k8s.io/client-go/util/exec"
+)
+
+// Options are the options of the exec command.
+type Options struct {
+	Command []string
+	Stderr  io.Writer
+	Stdin   *os.File
+	Stdout  io.Writer
+	// If true, a TTY is allocated for the command. Ignored if Stdin is not a terminal.
+	TTY bool
+	// If not empty, the command is run as this user using su, which requires su to be installed and the container to run as root.
+	User string
+}
+
+type execRunner struct {
+	cfg            *config.Config
+	composeService *config.Service
+	k8sClientset   *kubernetes.Clientset
+	opts           *Options
+}
+
+func (e *execRunner) initKubernetesClientset() error {
+	k8sClientset, err := kubernetes.NewForConfig(e.cfg.KubeConfig)
+	if err != nil {
+		return err
+	}
+	e.k8sClientset = k8sClientset
+	return nil
+}
+
+// shellQuote quotes each argument so that the result is interpreted as the same argument list by a POSIX shell.
+func shellQuote(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+func getCommand(opts *Options) []string {
+	if opts.User == "" {
+		return opts.Command
+	}
+	return []string{"su", "-s", "/bin/sh", "-c", "exec " + shellQuote(opts.Command), opts.User}
+}
+
+// sizeQueue implements remotecommand.TerminalSizeQueue by reporting the size of the local terminal whenever it is resized.
+type sizeQueue struct {
+	fd     int
+	resize chan os.Signal
+	first  bool
+}
+
+func (s *sizeQueue) Next() *remotecommand.TerminalSize {
+	if s.first {
+		s.first = false
+	} else if _, ok := <-s.resize; !ok {
+		return nil
+	}
+	width, height, err := terminal.GetSize(s.fd)
+	if err != nil {
+		log.Error(err)
+		return nil
+	}
+	return &remotecommand.TerminalSize{
+		Width:  uint16(width),
+		Height: uint16(height),
+	}
+}
+
+func (e *execRunner) stream(pod *v1.Pod, tty bool) error {
+	req := e.k8sClientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Name(pod.ObjectMeta.Name).
+		Namespace(e.cfg.Namespace).
+		SubResource("exec").
+		VersionedParams(&v1.PodExecOptions{
+			Container: pod.Spec.Containers[0].Name,
+			Command:   getCommand(e.opts),
+			Stdin:     e.opts.Stdin != nil,
+			Stdout:    true,
+			Stderr:    !tty,
+			TTY:       tty,
+		}, scheme.ParameterCodec)
+	executor, err := remotecommand.NewSPDYExecutor(e.cfg.KubeConfig, "POST", req.URL())
+	if err != nil {
+		return err
+	}
+	streamOptions := remotecommand.StreamOptions{
+		Stdout: e.opts.Stdout,
+		Tty:    tty,
+	}
+	if e.opts.Stdin != nil {
+		streamOptions.Stdin = e.opts.Stdin
+	}
+	if !tty {
+		streamOptions.Stderr = e.opts.Stderr
+		return executor.Stream(streamOptions)
+	}
+	fd := int(e.opts.Stdin.Fd())
+	state, err := terminal.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := terminal.Restore(fd, state); err != nil {
+			log.Error(err)
+		}
+	}()
+	queue := &sizeQueue{
+		fd:     fd,
+		resize: make(chan os.Signal, 1),
+		first:  true,
+	}
+	notifyResize(queue.resize)
+	defer func() {
+		signal.Stop(queue.resize)
+		close(queue.resize)
+	}()
+	streamOptions.TerminalSizeQueue = queue
+	return executor.Stream(streamOptions)
+}
+
+func (e *execRunner) run() (int, error) {
+	err := e.initKubernetesClientset()
+	if err != nil {
+		return 0, err
+	}
+	podList, err := e.k8sClientset.CoreV1().Pods(e.cfg.Namespace).List(metav1.ListOptions{
+		LabelSelector: e.cfg.EnvironmentLabel + "=" + e.cfg.EnvironmentID + ",app=" + e.composeService.NameEscaped,
+	})
+	if err != nil {
+		return 0, err
+	}
+	pod := k8smeta.FindRunningPod(e.cfg, e.composeService, podList.Items)
+	if pod == nil {
+		return 0, fmt.Errorf("no running pod found for docker compose service %s", e.composeService.Name())
+	}
+	tty := e.opts.TTY && e.opts.Stdin != nil && terminal.IsTerminal(int(e.opts.Stdin.Fd()))
+	err = e.stream(pod, tty)
+	if exitError, ok := err.(utilExec.ExitError); ok && exitError.Exited() {
+		return exitError.ExitStatus(), nil
+	}
+	return 0, err
+}
+
+// Run runs a command in a running pod of the docker compose service, and returns the exit code of the command.
+func Run(cfg *config.Config, composeService *config.Service, opts *Options) (int, error) {
+	e := &execRunner{
+		cfg:            cfg,
+		composeService: composeService,
+		opts:           opts,
+	}
+	return e.run()
+}
@@ -0,0 +1,33 @@
+package exec
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	s := shellQuote([]string{"echo", "it's"})
+	if s != `'echo' 'it'\''s'` {
+		t.Error(s)
+	}
+}
+
+func TestGetCommand_User(t *testing.T) {
+	command := getCommand(&Options{
+		Command: []string{"id", "-u"},
+		User:    "nobody",
+	})
+	expected := []string{"su", "-s", "/bin/sh", "-c", "exec 'id' '-u'", "nobody"}
+	if !reflect.DeepEqual(command, expected) {
+		t.Error(command)
+	}
+}
+
+func TestGetCommand_NoUser(t *testing.T) {
+	command := getCommand(&Options{
+		Command: []string{"id", "-u"},
+	})
+	if !reflect.DeepEqual(command, []string{"id", "-u"}) {
+		t.Error(command)
+	}
+}
@@ -0,0 +1,9 @@
+package exec
+
+import (
+	"os"
+)
+
+// Windows has no signal for terminal resizes, so only the initial size of the terminal is reported.
+func notifyResize(c chan<- os.Signal) {
+}
@@ -0,0 +1,239 @@
+package gc
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/down"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Options are the options of the gc command.
+type Options struct {
+	// If positive, environments whose resources were all created longer than OlderThan ago are stale. Environments whose resources all
+	// have an expired time to live are always stale.
+	OlderThan time.Duration
+}
+
+// Resource is a Kubernetes resource that belongs to an environment.
+type Resource struct {
+	Kind string
+	Name string
+	gvr  schema.GroupVersionResource
+}
+
+// Environment is a group of Kubernetes resources with the same environment label.
+type Environment struct {
+	ID string
+	// The time at which the most recently created resource of the environment was created.
+	CreatedAt time.Time
+	// Resources are ordered such that controllers are deleted before the pods they manage, and namespaces are deleted last.
+	Resources []Resource
+	expired   bool
+}
+
+type gcRunner struct {
+	cfg               *config.Config
+	dynamicClient     dynamic.Interface
+	discoverResources func() ([]schema.GroupVersionResource, error)
+	environments      map[string]*Environment
+	now               time.Time
+	opts              *Options
+	// The UIDs of found resources. The same resource may be listed through multiple API groups (e.g. deployments in apps and
+	// extensions), but should only be deleted once.
+	seenUIDs map[types.UID]bool
+}
+
+// initKubernetesClientset discovers resources the same way as down, so that gc deletes resources of any kind created by up.
+func (g *gcRunner) initKubernetesClientset() error {
+	k8sClientset, err := kubernetes.NewForConfig(g.cfg.KubeConfig)
+	if err != nil {
+		return err
+	}
+	dynamicClient, err := dynamic.NewForConfig(g.cfg.KubeConfig)
+	if err != nil {
+		return err
+	}
+	g.dynamicClient = dynamicClient
+	g.discoverResources = func() ([]schema.GroupVersionResource, error) {
+		return down.DiscoverResources(k8sClientset.Discovery())
+	}
+	return nil
+}
+
+func (g *gcRunner) getResourceClient(gvr schema.GroupVersionResource) dynamic.ResourceInterface {
+	if gvr == down.NamespacesResource {
+		return g.dynamicClient.Resource(gvr)
+	}
+	return g.dynamicClient.Resource(gvr).Namespace(g.cfg.Namespace)
+}
+
+// expiresAt returns the time at which a resource becomes stale, and false if the resource never becomes stale.
+func (g *gcRunner) expiresAt(createdAt time.Time, labels map[string]string) (time.Time, bool) {
+	if ttl, err := strconv.ParseInt(labels[k8smeta.LabelTTL], 10, 64); err == nil {
+		return createdAt.Add(time.Duration(ttl) * time.Second), true
+	}
+	if g.opts.OlderThan > 0 {
+		return createdAt.Add(g.opts.OlderThan), true
+	}
+	return time.Time{}, false
+}
+
+func (g *gcRunner) addResource(gvr schema.GroupVersionResource, kind string, obj metav1.Object) {
+	if uid := obj.GetUID(); uid != "" {
+		if g.seenUIDs[uid] {
+			return
+		}
+		g.seenUIDs[uid] = true
+	}
+	labels := obj.GetLabels()
+	envID := labels[g.cfg.EnvironmentLabel]
+	createdAtUnix, err := strconv.ParseInt(labels[k8smeta.LabelCreatedAt], 10, 64)
+	if err != nil {
+		log.Debugf("ignoring %s %s because it has an invalid label %s", kind, obj.GetName(), k8smeta.LabelCreatedAt)
+		return
+	}
+	createdAt := time.Unix(createdAtUnix, 0)
+	env := g.environments[envID]
+	if env == nil {
+		env = &Environment{
+			ID:      envID,
+			expired: true,
+		}
+		g.environments[envID] = env
+	}
+	if createdAt.After(env.CreatedAt) {
+		env.CreatedAt = createdAt
+	}
+	expiresAt, ok := g.expiresAt(createdAt, labels)
+	if !ok || g.now.Before(expiresAt) {
+		env.expired = false
+	}
+	env.Resources = append(env.Resources, Resource{
+		Kind: kind,
+		Name: obj.GetName(),
+		gvr:  gvr,
+	})
+}
+
+// findResources adds the resources of a kind that have an environment label.
+func (g *gcRunner) findResources(gvr schema.GroupVersionResource) error {
+	list, err := g.getResourceClient(gvr).List(metav1.ListOptions{
+		LabelSelector: g.cfg.EnvironmentLabel + "," + k8smeta.LabelCreatedAt,
+	})
+	if k8sError.IsForbidden(err) {
+		log.Warnf("skipping resources of kind %s, because they cannot be listed: %v", gvr.Resource, err)
+		return nil
+	}
+	if k8sError.IsNotFound(err) || k8sError.IsMethodNotSupported(err) {
+		// kube-compose cannot have created resources of this kind.
+		log.Debugf("skipping %s: %v", gvr.Resource, err)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(list.Items); i++ {
+		item := &list.Items[i]
+		// Only consider ephemeral namespaces derived from the namespace (see config.SetEphemeralNamespace).
+		if gvr == down.NamespacesResource && !strings.HasPrefix(item.GetName(), g.cfg.Namespace+"-") {
+			continue
+		}
+		g.addResource(gvr, down.GetKind(item, gvr), item)
+	}
+	return nil
+}
+
+// getDeletionOrder returns the order in which resources of the resource are deleted, like down. Namespaces are deleted last, because
+// deleting a namespace deletes all resources in it.
+func getDeletionOrder(gvr schema.GroupVersionResource) int {
+	if gvr == down.NamespacesResource {
+		return down.GetPhase("") + 1
+	}
+	return down.GetPhase(gvr.Resource)
+}
+
+func (g *gcRunner) find() ([]*Environment, error) {
+	gvrs, err := g.discoverResources()
+	if err != nil {
+		return nil, err
+	}
+	gvrs = append(gvrs, down.NamespacesResource)
+	for _, gvr := range gvrs {
+		err = g.findResources(gvr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var stale []*Environment
+	for _, env := range g.environments {
+		if env.expired {
+			sort.SliceStable(env.Resources, func(i, j int) bool {
+				return getDeletionOrder(env.Resources[i].gvr) < getDeletionOrder(env.Resources[j].gvr)
+			})
+			stale = append(stale, env)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool {
+		return stale[i].ID < stale[j].ID
+	})
+	return stale, nil
+}
+
+func (g *gcRunner) delete(environments []*Environment) error {
+	// Use background propagation so that the pods of Deployments and Jobs are deleted as well.
+	propagationPolicy := metav1.DeletePropagationBackground
+	deleteOptions := &metav1.DeleteOptions{
+		PropagationPolicy: &propagationPolicy,
+	}
+	for _, env := range environments {
+		for _, resource := range env.Resources {
+			err := g.getResourceClient(resource.gvr).Delete(resource.Name, deleteOptions)
+			if err != nil && !k8sError.IsNotFound(err) {
+				return err
+			}
+			log.Infof("deleted %s %s\n", resource.Kind, resource.Name)
+		}
+	}
+	return nil
+}
+
+func newGcRunner(cfg *config.Config, opts *Options) *gcRunner {
+	return &gcRunner{
+		cfg:          cfg,
+		environments: map[string]*Environment{},
+		now:          time.Now(),
+		opts:         opts,
+		seenUIDs:     map[types.UID]bool{},
+	}
+}
+
+// Find returns the stale environments in the namespace of cfg, ordered by ID. An environment is stale if all its resources are stale.
+func Find(cfg *config.Config, opts *Options) ([]*Environment, error) {
+	g := newGcRunner(cfg, opts)
+	err := g.initKubernetesClientset()
+	if err != nil {
+		return nil, err
+	}
+	return g.find()
+}
+
+// Delete deletes all resources of the environments, which must have been returned by Find.
+func Delete(cfg *config.Config, environments []*Environment) error {
+	g := newGcRunner(cfg, &Options{})
+	err := g.initKubernetesClientset()
+	if err != nil {
+		return err
+	}
+	return g.delete(environments)
+}
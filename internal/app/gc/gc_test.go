@@ -0,0 +1,193 @@
+package gc
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/down"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+var podsResource = schema.GroupVersionResource{
+	Version:  "v1",
+	Resource: "pods",
+}
+
+var secretsResource = schema.GroupVersionResource{
+	Version:  "v1",
+	Resource: "secrets",
+}
+
+var servicesResource = schema.GroupVersionResource{
+	Version:  "v1",
+	Resource: "services",
+}
+
+var deploymentsResource = schema.GroupVersionResource{
+	Group:    "apps",
+	Version:  "v1",
+	Resource: "deployments",
+}
+
+func newTestGcRunner(olderThan time.Duration, objects ...runtime.Object) *gcRunner {
+	g := newGcRunner(&config.Config{
+		EnvironmentLabel: "env",
+		Namespace:        "ns1",
+	}, &Options{
+		OlderThan: olderThan,
+	})
+	g.now = time.Unix(10000, 0)
+	g.dynamicClient = fake.NewSimpleDynamicClient(runtime.NewScheme(), objects...)
+	g.discoverResources = func() ([]schema.GroupVersionResource, error) {
+		return []schema.GroupVersionResource{podsResource, secretsResource, servicesResource, deploymentsResource}, nil
+	}
+	return g
+}
+
+func newTestResource(apiVersion, kind, namespace, name, envID string, createdAt int64) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	obj.SetUID(types.UID(kind + "/" + name))
+	obj.SetLabels(map[string]string{
+		"env":                  envID,
+		k8smeta.LabelCreatedAt: strconv.FormatInt(createdAt, 10),
+	})
+	return obj
+}
+
+func newTestObjectMeta(name, envID string, createdAt int64, ttl string) *metav1.ObjectMeta {
+	objectMeta := &metav1.ObjectMeta{
+		Name: name,
+		Labels: map[string]string{
+			"env":                  envID,
+			k8smeta.LabelCreatedAt: strconv.FormatInt(createdAt, 10),
+		},
+	}
+	if ttl != "" {
+		objectMeta.Labels[k8smeta.LabelTTL] = ttl
+	}
+	return objectMeta
+}
+
+func TestGcRunnerAddResource_OlderThan(t *testing.T) {
+	g := newTestGcRunner(time.Hour)
+	g.addResource(podsResource, "Pod", newTestObjectMeta("a-env1", "env1", 1000, ""))
+	g.addResource(servicesResource, "Service", newTestObjectMeta("a-env1", "env1", 2000, ""))
+	g.addResource(podsResource, "Pod", newTestObjectMeta("a-env2", "env2", 1000, ""))
+	g.addResource(podsResource, "Pod", newTestObjectMeta("b-env2", "env2", 9000, ""))
+	env1 := g.environments["env1"]
+	if !env1.expired || len(env1.Resources) != 2 || env1.CreatedAt.Unix() != 2000 {
+		t.Error(env1)
+	}
+	if g.environments["env2"].expired {
+		t.Fail()
+	}
+}
+
+func TestGcRunnerAddResource_TTL(t *testing.T) {
+	g := newTestGcRunner(0)
+	g.addResource(podsResource, "Pod", newTestObjectMeta("a-env1", "env1", 1000, "60"))
+	g.addResource(podsResource, "Pod", newTestObjectMeta("a-env2", "env2", 1000, "86400"))
+	g.addResource(podsResource, "Pod", newTestObjectMeta("a-env3", "env3", 1000, ""))
+	if !g.environments["env1"].expired || g.environments["env2"].expired || g.environments["env3"].expired {
+		t.Fail()
+	}
+}
+
+func TestGcRunnerAddResource_InvalidCreatedAt(t *testing.T) {
+	g := newTestGcRunner(time.Hour)
+	objectMeta := newTestObjectMeta("a-env1", "env1", 1000, "")
+	objectMeta.Labels[k8smeta.LabelCreatedAt] = "yesterday"
+	g.addResource(podsResource, "Pod", objectMeta)
+	if len(g.environments) != 0 {
+		t.Fail()
+	}
+}
+
+func TestGcRunnerFind_Success(t *testing.T) {
+	g := newTestGcRunner(time.Hour,
+		newTestResource("v1", "Pod", "ns1", "a-env1", "env1", 1000),
+		newTestResource("v1", "Secret", "ns1", "kube-compose-image-pull-env1", "env1", 1000),
+		newTestResource("apps/v1", "Deployment", "ns1", "b-env1", "env1", 1000),
+		newTestResource("v1", "Namespace", "", "ns1-env1", "env1", 1000),
+		newTestResource("v1", "Namespace", "", "other-env1", "env1", 1000),
+		newTestResource("v1", "Pod", "ns1", "a-env2", "env2", 9000),
+	)
+	stale, err := g.find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 1 || stale[0].ID != "env1" {
+		t.Fatal(stale)
+	}
+	var kinds []string
+	for _, resource := range stale[0].Resources {
+		kinds = append(kinds, resource.Kind+"/"+resource.Name)
+	}
+	expected := []string{"Deployment/b-env1", "Pod/a-env1", "Secret/kube-compose-image-pull-env1", "Namespace/ns1-env1"}
+	if !reflect.DeepEqual(kinds, expected) {
+		t.Error(kinds)
+	}
+}
+
+func TestGcRunnerDelete_Success(t *testing.T) {
+	g := newTestGcRunner(time.Hour,
+		newTestResource("v1", "Pod", "ns1", "a-env1", "env1", 1000),
+		newTestResource("v1", "Secret", "ns1", "kube-compose-image-pull-env1", "env1", 1000),
+		newTestResource("v1", "Namespace", "", "ns1-env1", "env1", 1000),
+	)
+	stale, err := g.find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = g.delete(stale)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, gvr := range []schema.GroupVersionResource{podsResource, secretsResource} {
+		list, err := g.dynamicClient.Resource(gvr).Namespace("ns1").List(metav1.ListOptions{})
+		if err != nil || len(list.Items) > 0 {
+			t.Error(gvr, list, err)
+		}
+	}
+	list, err := g.dynamicClient.Resource(down.NamespacesResource).List(metav1.ListOptions{})
+	if err != nil || len(list.Items) > 0 {
+		t.Error(list, err)
+	}
+}
+
+func TestGcRunnerFind_ImagePullSecret(t *testing.T) {
+	g := newTestGcRunner(time.Hour)
+	// The image pull secret of up is not created for a docker compose service, and only has the environment labels.
+	cfg := &config.Config{
+		EnvironmentLabel: "env",
+		EnvironmentID:    "env1",
+	}
+	secret := &unstructured.Unstructured{}
+	secret.SetAPIVersion("v1")
+	secret.SetKind("Secret")
+	secret.SetNamespace("ns1")
+	secret.SetName("kube-compose-image-pull-env1")
+	secret.SetLabels(k8smeta.InitEnvironmentLabels(cfg, nil))
+	g.dynamicClient = fake.NewSimpleDynamicClient(runtime.NewScheme(), secret)
+	g.now = time.Now().Add(2 * time.Hour)
+	stale, err := g.find()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(stale) != 1 || len(stale[0].Resources) != 1 || stale[0].Resources[0].Kind != "Secret" {
+		t.Fatal(stale)
+	}
+}
@@ -1,32 +1,76 @@
 package details
 
 import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
 	"github.com/kube-compose/kube-compose/internal/app/config"
 	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/app/podstatus"
+	"github.com/kube-compose/kube-compose/internal/app/ps"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	clientExtensionsV1beta1 "k8s.io/client-go/kubernetes/typed/extensions/v1beta1"
 )
 
 type getRunner struct {
 	cfg              *config.Config
 	k8sClientset     *kubernetes.Clientset
+	k8sIngressClient clientExtensionsV1beta1.IngressInterface
+	k8sNodeClient    clientV1.NodeInterface
+	k8sPodClient     clientV1.PodInterface
 	k8sServiceClient clientV1.ServiceInterface
-	service          *config.Service
+	// The pods of the environment by docker compose service, excluding pods created by the run command.
+	podsByService map[*config.Service][]v1.Pod
 }
 
 type ServiceDetails struct {
-	Name      string
-	ClusterIP string
-	Hostname  string
+	Name      string `json:"name"`
+	ClusterIP string `json:"clusterIP"`
+	Hostname  string `json:"hostname"`
+	// URLs through which the published ports of the service can be reached from outside the cluster. Empty if the service is not exposed.
+	ExternalURLs []string `json:"externalURLs"`
+	// The internal and published ports of the service, in the format of docker ps (e.g. 8236->8234/tcp).
+	Ports []string `json:"ports"`
+	// The name of a pod of the service, preferring a running pod. Empty if the service has no pods.
+	Pod   string `json:"pod"`
+	PodIP string `json:"podIP"`
+	Ready bool   `json:"ready"`
+	// The image of the pod, or the image in the docker compose file if the service has no pods.
+	Image string `json:"image"`
 }
 
-func GetServiceDetails(cfg *config.Config, service *config.Service) (*ServiceDetails, error) {
-	getRunner := &getRunner{
-		cfg:     cfg,
-		service: service,
+// GetServicesDetails returns the details of the docker compose services that match the filter of cfg directly, sorted by name.
+func GetServicesDetails(cfg *config.Config) ([]*ServiceDetails, error) {
+	g := &getRunner{
+		cfg: cfg,
+	}
+	err := g.init()
+	if err != nil {
+		return nil, err
 	}
-	return getRunner.run()
+	var services []*config.Service
+	for _, service := range cfg.Services {
+		if cfg.MatchesFilterDirectly(service) {
+			services = append(services, service)
+		}
+	}
+	sort.Slice(services, func(i, j int) bool {
+		return services[i].Name() < services[j].Name()
+	})
+	result := make([]*ServiceDetails, len(services))
+	for i, service := range services {
+		result[i], err = g.getServiceDetails(service)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
 }
 
 func (g *getRunner) initKubernetesClientset() error {
@@ -35,24 +79,199 @@ func (g *getRunner) initKubernetesClientset() error {
 		return err
 	}
 	g.k8sClientset = k8sClientset
+	g.k8sIngressClient = g.k8sClientset.ExtensionsV1beta1().Ingresses(g.cfg.Namespace)
+	g.k8sNodeClient = g.k8sClientset.CoreV1().Nodes()
+	g.k8sPodClient = g.k8sClientset.CoreV1().Pods(g.cfg.Namespace)
 	g.k8sServiceClient = g.k8sClientset.CoreV1().Services(g.cfg.Namespace)
 	return nil
 }
 
-func (g *getRunner) run() (*ServiceDetails, error) {
+func (g *getRunner) init() error {
 	err := g.initKubernetesClientset()
 	if err != nil {
-		return nil, err
+		return err
+	}
+	podList, err := g.k8sPodClient.List(metav1.ListOptions{
+		LabelSelector: g.cfg.EnvironmentLabel + "=" + g.cfg.EnvironmentID,
+	})
+	if err != nil {
+		return err
 	}
-	k8sName := k8smeta.GetK8sName(g.service, g.cfg)
+	g.podsByService = groupPodsByService(g.cfg, podList.Items)
+	return nil
+}
+
+func groupPodsByService(cfg *config.Config, pods []v1.Pod) map[*config.Service][]v1.Pod {
+	podsByService := map[*config.Service][]v1.Pod{}
+	for _, pod := range pods {
+		service := k8smeta.FindFromObjectMeta(cfg, &pod.ObjectMeta)
+		if service != nil && !k8smeta.IsOneOff(&pod.ObjectMeta) {
+			podsByService[service] = append(podsByService[service], pod)
+		}
+	}
+	return podsByService
+}
+
+// setPodDetails sets the pod details of the service, preferring a running pod and otherwise using the pod whose name sorts first.
+func setPodDetails(cfg *config.Config, service *config.Service, pods []v1.Pod, details *ServiceDetails) {
+	details.Image = service.DockerComposeService.Image
+	pod := k8smeta.FindRunningPod(cfg, service, pods)
+	if pod == nil {
+		for i := 0; i < len(pods); i++ {
+			if pod == nil || pods[i].ObjectMeta.Name < pod.ObjectMeta.Name {
+				pod = &pods[i]
+			}
+		}
+	}
+	if pod == nil {
+		return
+	}
+	details.Pod = pod.ObjectMeta.Name
+	details.PodIP = pod.Status.PodIP
+	details.Ready = podstatus.IsPodReady(pod)
+	if len(pod.Spec.Containers) > 0 {
+		details.Image = pod.Spec.Containers[0].Image
+	}
+}
+
+func (g *getRunner) getServiceDetails(service *config.Service) (*ServiceDetails, error) {
+	k8sName := k8smeta.GetK8sServiceName(service, g.cfg)
 	result, err := g.k8sServiceClient.Get(k8sName, metav1.GetOptions{})
 	if err != nil {
 		return nil, err
 	}
 	details := &ServiceDetails{
-		Name:      g.service.Name(),
+		Name:      service.Name(),
 		Hostname:  result.Name + "." + result.Namespace + ".svc.cluster.local",
 		ClusterIP: result.Spec.ClusterIP,
+		Ports:     ps.FormatPorts(service.DockerComposeService),
+	}
+	setPodDetails(g.cfg, service, g.podsByService[service], details)
+	details.ExternalURLs, err = g.getExternalURLs(service, result)
+	if err != nil {
+		return nil, err
 	}
 	return details, nil
 }
+
+func (g *getRunner) getExternalURLs(composeService *config.Service, service *v1.Service) ([]string, error) {
+	if composeService.Expose == nil {
+		return nil, nil
+	}
+	switch composeService.Expose.Type {
+	case config.ExposeTypeNodePort:
+		host, err := g.getNodeHost()
+		if err != nil {
+			return nil, err
+		}
+		return formatNodePortURLs(host, service, getPublishedPorts(composeService)), nil
+	case config.ExposeTypeLoadBalancer:
+		return formatLoadBalancerURLs(service, getPublishedPorts(composeService)), nil
+	case config.ExposeTypeIngress:
+		ingress, err := g.k8sIngressClient.Get(k8smeta.GetK8sName(composeService, g.cfg), metav1.GetOptions{})
+		if k8sError.IsNotFound(err) {
+			// up does not create an ingress for services without published tcp ports.
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		var urls []string
+		for _, rule := range ingress.Spec.Rules {
+			urls = append(urls, "http://"+rule.Host)
+		}
+		return urls, nil
+	}
+	return nil, nil
+}
+
+// getNodeHost returns an address of a node of the cluster. If nodes cannot be listed (e.g. due to missing permissions), or no node has an
+// address, the host of the Kubernetes API server is returned instead. This is typically the case for single node clusters such as
+// Docker Desktop and minikube.
+func (g *getRunner) getNodeHost() (string, error) {
+	nodeList, err := g.k8sNodeClient.List(metav1.ListOptions{})
+	if err == nil {
+		if host := findNodeAddress(nodeList.Items); host != "" {
+			return host, nil
+		}
+	}
+	u, err := url.Parse(g.cfg.KubeConfig.Host)
+	if err != nil {
+		return "", err
+	}
+	if u.Hostname() == "" {
+		return "", fmt.Errorf("could not determine the address of a node of the cluster")
+	}
+	return u.Hostname(), nil
+}
+
+// findNodeAddress returns the external IP of the first node that has one, otherwise the internal IP of the first node that has one.
+func findNodeAddress(nodes []v1.Node) string {
+	for _, addressType := range []v1.NodeAddressType{v1.NodeExternalIP, v1.NodeInternalIP} {
+		for i := range nodes {
+			for _, address := range nodes[i].Status.Addresses {
+				if address.Type == addressType && address.Address != "" {
+					return address.Address
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func formatURL(protocol v1.Protocol, host string, port int32) string {
+	return fmt.Sprintf("%s://%s:%d", strings.ToLower(string(protocol)), host, port)
+}
+
+// publishedPortKey identifies a published port of a docker compose service.
+type publishedPortKey struct {
+	port     int32
+	protocol string
+}
+
+// getPublishedPorts returns the published ports of a docker compose service, which up adds to the Kubernetes Service as service ports
+// with the published port as port.
+func getPublishedPorts(composeService *config.Service) map[publishedPortKey]bool {
+	published := map[publishedPortKey]bool{}
+	for _, port := range composeService.DockerComposeService.Ports {
+		if port.ExternalMin < 0 {
+			continue
+		}
+		for external := port.ExternalMin; external <= port.ExternalMax; external++ {
+			published[publishedPortKey{port: external, protocol: strings.ToLower(port.Protocol)}] = true
+		}
+	}
+	return published
+}
+
+func isPublishedServicePort(published map[publishedPortKey]bool, port *v1.ServicePort) bool {
+	return published[publishedPortKey{port: port.Port, protocol: strings.ToLower(string(port.Protocol))}]
+}
+
+func formatNodePortURLs(host string, service *v1.Service, published map[publishedPortKey]bool) []string {
+	var urls []string
+	for i := range service.Spec.Ports {
+		port := &service.Spec.Ports[i]
+		if port.NodePort != 0 && isPublishedServicePort(published, port) {
+			urls = append(urls, formatURL(port.Protocol, host, port.NodePort))
+		}
+	}
+	return urls
+}
+
+func formatLoadBalancerURLs(service *v1.Service, published map[publishedPortKey]bool) []string {
+	var urls []string
+	for _, ingress := range service.Status.LoadBalancer.Ingress {
+		host := ingress.IP
+		if host == "" {
+			host = ingress.Hostname
+		}
+		for i := range service.Spec.Ports {
+			port := &service.Spec.Ports[i]
+			if isPublishedServicePort(published, port) {
+				urls = append(urls, formatURL(port.Protocol, host, port.Port))
+			}
+		}
+	}
+	return urls
+}
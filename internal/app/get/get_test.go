@@ -0,0 +1,256 @@
+package details
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	extensionsV1beta1 "k8s.io/api/extensions/v1beta1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientExtensionsV1beta1 "k8s.io/client-go/kubernetes/typed/extensions/v1beta1"
+)
+
+func TestFindNodeAddress_PrefersExternalIP(t *testing.T) {
+	nodes := []v1.Node{
+		{
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{
+					{Type: v1.NodeInternalIP, Address: "10.0.0.1"},
+				},
+			},
+		},
+		{
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{
+					{Type: v1.NodeInternalIP, Address: "10.0.0.2"},
+					{Type: v1.NodeExternalIP, Address: "1.2.3.4"},
+				},
+			},
+		},
+	}
+	if host := findNodeAddress(nodes); host != "1.2.3.4" {
+		t.Fail()
+	}
+	nodes[1].Status.Addresses = nodes[1].Status.Addresses[:1]
+	if host := findNodeAddress(nodes); host != "10.0.0.1" {
+		t.Fail()
+	}
+	if host := findNodeAddress(nil); host != "" {
+		t.Fail()
+	}
+}
+
+func TestFormatNodePortURLs_Success(t *testing.T) {
+	service := &v1.Service{
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{Port: 80, NodePort: 30080, Protocol: v1.ProtocolTCP},
+				{Port: 53, NodePort: 30053, Protocol: v1.ProtocolUDP},
+				{Port: 81},
+				// An internal port that is not published.
+				{Port: 8080, NodePort: 30081, Protocol: v1.ProtocolTCP},
+			},
+		},
+	}
+	published := map[publishedPortKey]bool{
+		{port: 80, protocol: "tcp"}: true,
+		{port: 53, protocol: "udp"}: true,
+	}
+	urls := formatNodePortURLs("1.2.3.4", service, published)
+	expected := []string{"tcp://1.2.3.4:30080", "udp://1.2.3.4:30053"}
+	if !reflect.DeepEqual(urls, expected) {
+		t.Error(urls)
+	}
+}
+
+func TestFormatLoadBalancerURLs_Success(t *testing.T) {
+	service := &v1.Service{
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{Port: 80, Protocol: v1.ProtocolTCP},
+				{Port: 8080, Protocol: v1.ProtocolTCP},
+			},
+		},
+		Status: v1.ServiceStatus{
+			LoadBalancer: v1.LoadBalancerStatus{
+				Ingress: []v1.LoadBalancerIngress{
+					{IP: "1.2.3.4"},
+					{Hostname: "lb.example.com"},
+				},
+			},
+		},
+	}
+	urls := formatLoadBalancerURLs(service, map[publishedPortKey]bool{
+		{port: 80, protocol: "tcp"}: true,
+	})
+	expected := []string{"tcp://1.2.3.4:80", "tcp://lb.example.com:80"}
+	if !reflect.DeepEqual(urls, expected) {
+		t.Error(urls)
+	}
+}
+
+func TestGetPublishedPorts(t *testing.T) {
+	cfg := &config.Config{}
+	composeService := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+		Ports: []dockerComposeConfig.PortBinding{
+			{Internal: 80, ExternalMin: 8080, ExternalMax: 8081, Protocol: "tcp"},
+			{Internal: 53, ExternalMin: -1, Protocol: "udp"},
+		},
+	})
+	expected := map[publishedPortKey]bool{
+		{port: 8080, protocol: "tcp"}: true,
+		{port: 8081, protocol: "tcp"}: true,
+	}
+	if actual := getPublishedPorts(composeService); !reflect.DeepEqual(actual, expected) {
+		t.Error(actual)
+	}
+}
+
+type testIngressClient struct {
+	clientExtensionsV1beta1.IngressInterface
+	ingresses map[string]*extensionsV1beta1.Ingress
+}
+
+func (c *testIngressClient) Get(name string, options metav1.GetOptions) (*extensionsV1beta1.Ingress, error) {
+	if ingress, ok := c.ingresses[name]; ok {
+		return ingress, nil
+	}
+	return nil, k8sError.NewNotFound(extensionsV1beta1.Resource("ingresses"), name)
+}
+
+func newTestIngressGetRunner() (*getRunner, *config.Service, *config.Service) {
+	cfg := &config.Config{
+		EnvironmentID: "env1",
+	}
+	expose := &config.Expose{
+		Type: config.ExposeTypeIngress,
+	}
+	a := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	a.Expose = expose
+	b := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "b",
+	})
+	b.Expose = expose
+	g := &getRunner{
+		cfg: cfg,
+		k8sIngressClient: &testIngressClient{
+			ingresses: map[string]*extensionsV1beta1.Ingress{
+				"a-env1": {
+					Spec: extensionsV1beta1.IngressSpec{
+						Rules: []extensionsV1beta1.IngressRule{
+							{Host: "a.example.com"},
+						},
+					},
+				},
+			},
+		},
+	}
+	return g, a, b
+}
+
+func TestGetExternalURLs_Ingress(t *testing.T) {
+	g, a, _ := newTestIngressGetRunner()
+	urls, err := g.getExternalURLs(a, &v1.Service{})
+	if err != nil || !reflect.DeepEqual(urls, []string{"http://a.example.com"}) {
+		t.Error(urls, err)
+	}
+}
+
+func TestGetExternalURLs_IngressNotFound(t *testing.T) {
+	g, _, b := newTestIngressGetRunner()
+	urls, err := g.getExternalURLs(b, &v1.Service{})
+	if err != nil || len(urls) > 0 {
+		t.Error(urls, err)
+	}
+}
+
+func newTestPod(name, service string, phase v1.PodPhase) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Annotations: map[string]string{
+				k8smeta.AnnotationName: service,
+			},
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{Image: "ubuntu:" + name},
+			},
+		},
+		Status: v1.PodStatus{
+			Phase: phase,
+			PodIP: "172.17.0.2",
+		},
+	}
+}
+
+func TestGroupPodsByService(t *testing.T) {
+	cfg := &config.Config{}
+	a := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	oneOffPod := newTestPod("a-env1-run-x", "a", v1.PodRunning)
+	oneOffPod.ObjectMeta.Annotations[k8smeta.AnnotationOneOff] = "true"
+	pods := []v1.Pod{
+		newTestPod("a-env1", "a", v1.PodRunning),
+		newTestPod("b-env1", "b", v1.PodRunning),
+		oneOffPod,
+	}
+	podsByService := groupPodsByService(cfg, pods)
+	if len(podsByService) != 1 || len(podsByService[a]) != 1 || podsByService[a][0].ObjectMeta.Name != "a-env1" {
+		t.Error(podsByService)
+	}
+}
+
+func TestSetPodDetails_Running(t *testing.T) {
+	cfg := &config.Config{}
+	a := cfg.AddService(&dockerComposeConfig.Service{
+		Name:  "a",
+		Image: "ubuntu:latest",
+	})
+	pods := []v1.Pod{
+		newTestPod("a-env1-1", "a", v1.PodPending),
+		newTestPod("a-env1-2", "a", v1.PodRunning),
+	}
+	details := &ServiceDetails{}
+	setPodDetails(cfg, a, pods, details)
+	if details.Pod != "a-env1-2" || details.PodIP != "172.17.0.2" || details.Ready || details.Image != "ubuntu:a-env1-2" {
+		t.Error(details)
+	}
+}
+
+func TestSetPodDetails_NotRunning(t *testing.T) {
+	cfg := &config.Config{}
+	a := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	pods := []v1.Pod{
+		newTestPod("a-env1-2", "a", v1.PodPending),
+		newTestPod("a-env1-1", "a", v1.PodSucceeded),
+	}
+	details := &ServiceDetails{}
+	setPodDetails(cfg, a, pods, details)
+	if details.Pod != "a-env1-1" {
+		t.Error(details)
+	}
+}
+
+func TestSetPodDetails_NoPods(t *testing.T) {
+	cfg := &config.Config{}
+	a := cfg.AddService(&dockerComposeConfig.Service{
+		Name:  "a",
+		Image: "ubuntu:latest",
+	})
+	details := &ServiceDetails{}
+	setPodDetails(cfg, a, nil, details)
+	if details.Pod != "" || details.Image != "ubuntu:latest" {
+		t.Error(details)
+	}
+}
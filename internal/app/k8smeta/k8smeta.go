@@ -2,8 +2,14 @@ package k8smeta
 
 import (
 	"fmt"
+	"os"
+	"os/user"
+	"sort"
+	"strconv"
+	"time"
 
 	"github.com/kube-compose/kube-compose/internal/app/config"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -11,6 +17,46 @@ import (
 // compose service.
 const AnnotationName = "kube-compose/service"
 
+// LabelCreatedAt is the name of a label added by kube compose to resources, whose value is the time the resource was created in seconds
+// since the Unix epoch. Label values cannot contain colons, so RFC 3339 cannot be used.
+const LabelCreatedAt = "kube-compose/created-at"
+
+// LabelTTL is the name of a label added by kube compose to resources if the environment has a time to live, whose value is the time to
+// live in seconds.
+const LabelTTL = "kube-compose/ttl"
+
+// AnnotationCreatedBy is the name of an annotation added by kube compose to resources, whose value identifies the user that created the
+// resource in the form user@host.
+const AnnotationCreatedBy = "kube-compose/created-by"
+
+// ResourceQuotaName is the name of the ResourceQuota created by up in an ephemeral namespace.
+const ResourceQuotaName = "kube-compose"
+
+// GetImagePullSecretName returns the name of the Secret that up creates with the credentials of docker registries.
+func GetImagePullSecretName(cfg *config.Config) string {
+	return "kube-compose-image-pull-" + cfg.EnvironmentID
+}
+
+// AnnotationOneOff is the name of an annotation added by kube compose to pods created by the run command, so that they are not mistaken for
+// the pods of their docker compose service.
+const AnnotationOneOff = "kube-compose/one-off"
+
+var timeNow = time.Now
+
+var createdBy = getCreatedBy()
+
+func getCreatedBy() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return u.Username
+	}
+	return u.Username + "@" + hostname
+}
+
 // ErrorResourcesModifiedExternally returns an error indicating that resources managed by kube-compose have been modified externally.
 func ErrorResourcesModifiedExternally() error {
 	return fmt.Errorf("one or more resources appear to have been modified by an external process, aborting")
@@ -26,14 +72,38 @@ func InitCommonLabels(cfg *config.Config, composeService *config.Service, labels
 	return labels
 }
 
+// InitEnvironmentLabels adds the labels that identify the environment, and that are used to garbage collect the environment, to the string
+// map.
+func InitEnvironmentLabels(cfg *config.Config, labels map[string]string) map[string]string {
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[cfg.EnvironmentLabel] = cfg.EnvironmentID
+	labels[LabelCreatedAt] = strconv.FormatInt(timeNow().Unix(), 10)
+	if cfg.TTL > 0 {
+		labels[LabelTTL] = strconv.FormatInt(int64(cfg.TTL/time.Second), 10)
+	}
+	return labels
+}
+
 // InitObjectMeta sets the name, labels and annotations of a resource for the specified docker compose service.
 func InitObjectMeta(cfg *config.Config, objectMeta *metav1.ObjectMeta, composeService *config.Service) {
 	objectMeta.Name = GetK8sName(composeService, cfg)
 	objectMeta.Labels = InitCommonLabels(cfg, composeService, objectMeta.Labels)
+	objectMeta.Labels = InitEnvironmentLabels(cfg, objectMeta.Labels)
 	if objectMeta.Annotations == nil {
 		objectMeta.Annotations = map[string]string{}
 	}
 	objectMeta.Annotations[AnnotationName] = composeService.Name()
+	if createdBy != "" {
+		objectMeta.Annotations[AnnotationCreatedBy] = createdBy
+	}
+}
+
+// IsOneOff returns true if the resource metadata belongs to a pod created by the run command.
+func IsOneOff(objectMeta *metav1.ObjectMeta) bool {
+	_, ok := objectMeta.Annotations[AnnotationOneOff]
+	return ok
 }
 
 // FindFromObjectMeta finds a docker compose service from resource metadata.
@@ -47,6 +117,43 @@ func FindFromObjectMeta(cfg *config.Config, objectMeta *metav1.ObjectMeta) *conf
 	return nil
 }
 
+// FindRunningPod selects a running pod of the docker compose service, preferring the pod named after the docker compose service (as created
+// by the up command). Pods created by the run command are ignored. Returns nil if no such pod exists.
+func FindRunningPod(cfg *config.Config, composeService *config.Service, pods []v1.Pod) *v1.Pod {
+	name := GetK8sName(composeService, cfg)
+	var candidates []*v1.Pod
+	for i := 0; i < len(pods); i++ {
+		pod := &pods[i]
+		if pod.ObjectMeta.DeletionTimestamp != nil || pod.Status.Phase != v1.PodRunning || IsOneOff(&pod.ObjectMeta) {
+			continue
+		}
+		if FindFromObjectMeta(cfg, &pod.ObjectMeta) != composeService {
+			continue
+		}
+		if pod.ObjectMeta.Name == name {
+			return pod
+		}
+		candidates = append(candidates, pod)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].ObjectMeta.Name < candidates[j].ObjectMeta.Name
+	})
+	return candidates[0]
+}
+
 func GetK8sName(service *config.Service, cfg *config.Config) string {
 	return service.NameEscaped + "-" + cfg.EnvironmentID
 }
+
+// GetK8sServiceName returns the name of the Kubernetes Service of a docker compose service. If service discovery is through DNS, the
+// Kubernetes Service is named exactly like the docker compose service so that pods can resolve it, otherwise the name is the same as
+// GetK8sName.
+func GetK8sServiceName(service *config.Service, cfg *config.Config) string {
+	if cfg.ServiceDiscovery == config.ServiceDiscoveryDNS {
+		return service.NameEscaped
+	}
+	return GetK8sName(service, cfg)
+}
@@ -2,9 +2,11 @@ package k8smeta
 
 import (
 	"testing"
+	"time"
 
 	"github.com/kube-compose/kube-compose/internal/app/config"
 	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -39,6 +41,18 @@ func TestGetK8sName(t *testing.T) {
 	}
 }
 
+func TestGetK8sServiceName(t *testing.T) {
+	service := &config.Service{NameEscaped: "Test"}
+	cfg := &config.Config{EnvironmentID: "123"}
+	if serviceName := GetK8sServiceName(service, cfg); serviceName != "Test-123" {
+		t.Fail()
+	}
+	cfg.ServiceDiscovery = config.ServiceDiscoveryDNS
+	if serviceName := GetK8sServiceName(service, cfg); serviceName != "Test" {
+		t.Fail()
+	}
+}
+
 func TestFindFromObjectMeta_NotFound(t *testing.T) {
 	cfg := config.Config{}
 	objectMeta := metav1.ObjectMeta{}
@@ -65,3 +79,96 @@ func Test_ErrorResourcesModifiedExternally(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func TestInitEnvironmentLabels(t *testing.T) {
+	timeNowOrig := timeNow
+	defer func() {
+		timeNow = timeNowOrig
+	}()
+	timeNow = func() time.Time {
+		return time.Unix(1234, 0)
+	}
+	cfg := &config.Config{
+		EnvironmentID:    "myenv",
+		EnvironmentLabel: "env",
+	}
+	labels := InitEnvironmentLabels(cfg, nil)
+	if labels["env"] != "myenv" || labels[LabelCreatedAt] != "1234" {
+		t.Error(labels)
+	}
+	if _, ok := labels[LabelTTL]; ok {
+		t.Fail()
+	}
+	cfg.TTL = 2 * time.Hour
+	labels = InitEnvironmentLabels(cfg, nil)
+	if labels[LabelTTL] != "7200" {
+		t.Error(labels)
+	}
+}
+
+func newFindRunningPodTestPod(name, service string, phase v1.PodPhase) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+			Annotations: map[string]string{
+				AnnotationName: service,
+			},
+		},
+		Status: v1.PodStatus{
+			Phase: phase,
+		},
+	}
+}
+
+func newFindRunningPodTestConfig() (*config.Config, *config.Service) {
+	cfg := &config.Config{
+		EnvironmentID: "env1",
+	}
+	composeService := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	cfg.AddService(&dockerComposeConfig.Service{
+		Name: "b",
+	})
+	return cfg, composeService
+}
+
+func TestFindRunningPod_PrefersK8sName(t *testing.T) {
+	cfg, composeService := newFindRunningPodTestConfig()
+	pods := []v1.Pod{
+		newFindRunningPodTestPod("a-env1-1", "a", v1.PodRunning),
+		newFindRunningPodTestPod("a-env1", "a", v1.PodRunning),
+	}
+	pod := FindRunningPod(cfg, composeService, pods)
+	if pod != &pods[1] {
+		t.Fail()
+	}
+}
+
+func TestFindRunningPod_Running(t *testing.T) {
+	cfg, composeService := newFindRunningPodTestConfig()
+	pods := []v1.Pod{
+		newFindRunningPodTestPod("a-env1", "a", v1.PodPending),
+		newFindRunningPodTestPod("b-env1", "b", v1.PodRunning),
+		newFindRunningPodTestPod("a-env1-2", "a", v1.PodRunning),
+		newFindRunningPodTestPod("a-env1-1", "a", v1.PodRunning),
+	}
+	pod := FindRunningPod(cfg, composeService, pods)
+	if pod != &pods[3] {
+		t.Fail()
+	}
+}
+
+func TestFindRunningPod_NotFound(t *testing.T) {
+	cfg, composeService := newFindRunningPodTestConfig()
+	pods := []v1.Pod{
+		newFindRunningPodTestPod("a-env1", "a", v1.PodSucceeded),
+	}
+	now := metav1.Now()
+	pod := newFindRunningPodTestPod("a-env1-1", "a", v1.PodRunning)
+	pod.ObjectMeta.DeletionTimestamp = &now
+	pods = append(pods, pod)
+	if FindRunningPod(cfg, composeService, pods) != nil {
+		t.Fail()
+	}
+}
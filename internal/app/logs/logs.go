@@ -0,0 +1,214 @@
+package logs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+var colorPalette = []int{
+	37, // gray
+	36, // blue
+	35, // magenta
+	33, // yellow
+	32, // green
+}
+
+// Color returns the color of the i-th docker compose service, as an ANSI escape code.
+func Color(i int) int {
+	return colorPalette[i%len(colorPalette)]
+}
+
+// FormatLine prefixes a line of logs with the name of its source, padded to width, similar to docker-compose.
+func FormatLine(color, width int, name, line string) string {
+	if color == 0 {
+		return fmt.Sprintf("%-*s| %s", width, name, line)
+	}
+	return fmt.Sprintf("\x1b[%dm%-*s|\x1b[0m %s", color, width, name, line)
+}
+
+// Options are the options of the logs command.
+type Options struct {
+	Follow bool
+	// If true, lines are not colored.
+	NoColor  bool
+	Previous bool
+	// If positive, only logs newer than this duration are returned.
+	Since time.Duration
+	// If not nil, the number of lines from the end of the logs of each container.
+	Tail       *int64
+	Timestamps bool
+}
+
+type source struct {
+	color     int
+	container string
+	name      string
+	pod       string
+}
+
+type logsRunner struct {
+	cfg          *config.Config
+	k8sClientset *kubernetes.Clientset
+	k8sPodClient clientV1.PodInterface
+	mutex        sync.Mutex
+	opts         *Options
+	out          io.Writer
+	width        int
+}
+
+func (l *logsRunner) initKubernetesClientset() error {
+	k8sClientset, err := kubernetes.NewForConfig(l.cfg.KubeConfig)
+	if err != nil {
+		return err
+	}
+	l.k8sClientset = k8sClientset
+	l.k8sPodClient = l.k8sClientset.CoreV1().Pods(l.cfg.Namespace)
+	return nil
+}
+
+// getSources returns a source for each container (including init containers) of the pods. The name of a source is the name of the docker
+// compose service, or the name of the pod if the docker compose service has multiple pods. Init containers are suffixed with their name.
+func (l *logsRunner) getSources(pods []*v1.Pod, composeServices []*config.Service) []*source {
+	podCount := map[*config.Service]int{}
+	for _, composeService := range composeServices {
+		podCount[composeService]++
+	}
+	colors := map[*config.Service]int{}
+	var sources []*source
+	for i, pod := range pods {
+		composeService := composeServices[i]
+		color, ok := colors[composeService]
+		if !ok {
+			color = 0
+			if !l.opts.NoColor {
+				color = Color(len(colors))
+			}
+			colors[composeService] = color
+		}
+		name := composeService.Name()
+		if podCount[composeService] > 1 {
+			name = pod.ObjectMeta.Name
+		}
+		for _, container := range pod.Spec.InitContainers {
+			sources = append(sources, &source{
+				color:     color,
+				container: container.Name,
+				name:      name + "/" + container.Name,
+				pod:       pod.ObjectMeta.Name,
+			})
+		}
+		for _, container := range pod.Spec.Containers {
+			sources = append(sources, &source{
+				color:     color,
+				container: container.Name,
+				name:      name,
+				pod:       pod.ObjectMeta.Name,
+			})
+		}
+	}
+	return sources
+}
+
+func (l *logsRunner) newPodLogOptions(container string) *v1.PodLogOptions {
+	podLogOptions := &v1.PodLogOptions{
+		Container:  container,
+		Follow:     l.opts.Follow,
+		Previous:   l.opts.Previous,
+		TailLines:  l.opts.Tail,
+		Timestamps: l.opts.Timestamps,
+	}
+	if l.opts.Since > 0 {
+		sinceSeconds := int64(l.opts.Since / time.Second)
+		podLogOptions.SinceSeconds = &sinceSeconds
+	}
+	return podLogOptions
+}
+
+func (l *logsRunner) writeLine(s *source, line string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	fmt.Fprintln(l.out, FormatLine(s.color, l.width, s.name, line))
+}
+
+func (l *logsRunner) streamLogs(s *source) {
+	bodyReader, err := l.k8sPodClient.GetLogs(s.pod, l.newPodLogOptions(s.container)).Stream()
+	if err != nil {
+		// For example, the container has not started yet, or has no previous instance.
+		log.Warnf("could not get the logs of container %s of pod %s: %v", s.container, s.pod, err)
+		return
+	}
+	defer util.CloseAndLogError(bodyReader)
+	scanner := bufio.NewScanner(bodyReader)
+	for scanner.Scan() {
+		l.writeLine(s, scanner.Text())
+	}
+	if err = scanner.Err(); err != nil {
+		log.Error(err)
+	}
+}
+
+func (l *logsRunner) run() error {
+	err := l.initKubernetesClientset()
+	if err != nil {
+		return err
+	}
+	podList, err := l.k8sPodClient.List(metav1.ListOptions{
+		LabelSelector: l.cfg.EnvironmentLabel + "=" + l.cfg.EnvironmentID,
+	})
+	if err != nil {
+		return err
+	}
+	sort.Slice(podList.Items, func(i, j int) bool {
+		return podList.Items[i].ObjectMeta.Name < podList.Items[j].ObjectMeta.Name
+	})
+	var pods []*v1.Pod
+	var composeServices []*config.Service
+	for i := 0; i < len(podList.Items); i++ {
+		pod := &podList.Items[i]
+		composeService := k8smeta.FindFromObjectMeta(l.cfg, &pod.ObjectMeta)
+		if composeService != nil && l.cfg.MatchesFilterDirectly(composeService) {
+			pods = append(pods, pod)
+			composeServices = append(composeServices, composeService)
+		}
+	}
+	sources := l.getSources(pods, composeServices)
+	for _, s := range sources {
+		if len(s.name)+3 > l.width {
+			l.width = len(s.name) + 3
+		}
+	}
+	var wg sync.WaitGroup
+	for _, s := range sources {
+		wg.Add(1)
+		go func(s *source) {
+			defer wg.Done()
+			l.streamLogs(s)
+		}(s)
+	}
+	wg.Wait()
+	return nil
+}
+
+// Run writes the logs of all containers of the pods of the docker compose services that match the filter of cfg directly to out. Lines of
+// different containers are interleaved in the order they are received.
+func Run(cfg *config.Config, opts *Options, out io.Writer) error {
+	l := &logsRunner{
+		cfg:  cfg,
+		opts: opts,
+		out:  out,
+	}
+	return l.run()
+}
@@ -0,0 +1,135 @@
+package logs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestColor_Cycles(t *testing.T) {
+	for i := 0; i < len(colorPalette); i++ {
+		if Color(i) != Color(i+len(colorPalette)) {
+			t.Fail()
+		}
+	}
+}
+
+func TestFormatLine_Color(t *testing.T) {
+	line := FormatLine(36, 5, "a", "hello")
+	if line != "\x1b[36ma    |\x1b[0m hello" {
+		t.Error(line)
+	}
+}
+
+func TestFormatLine_NoColor(t *testing.T) {
+	line := FormatLine(0, 5, "a", "hello")
+	if line != "a    | hello" {
+		t.Error(line)
+	}
+}
+
+func newTestPod(name string) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: v1.PodSpec{
+			InitContainers: []v1.Container{
+				{Name: "volume-init"},
+			},
+			Containers: []v1.Container{
+				{Name: "main"},
+			},
+		},
+	}
+}
+
+func TestLogsRunnerGetSources(t *testing.T) {
+	cfg := &config.Config{}
+	a := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	b := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "b",
+	})
+	l := &logsRunner{
+		cfg:  cfg,
+		opts: &Options{},
+	}
+	sources := l.getSources(
+		[]*v1.Pod{newTestPod("a-env1"), newTestPod("b-env1"), newTestPod("b-env1-2")},
+		[]*config.Service{a, b, b},
+	)
+	expected := []source{
+		{color: Color(0), container: "volume-init", name: "a/volume-init", pod: "a-env1"},
+		{color: Color(0), container: "main", name: "a", pod: "a-env1"},
+		{color: Color(1), container: "volume-init", name: "b-env1/volume-init", pod: "b-env1"},
+		{color: Color(1), container: "main", name: "b-env1", pod: "b-env1"},
+		{color: Color(1), container: "volume-init", name: "b-env1-2/volume-init", pod: "b-env1-2"},
+		{color: Color(1), container: "main", name: "b-env1-2", pod: "b-env1-2"},
+	}
+	if len(sources) != len(expected) {
+		t.Fatal(len(sources))
+	}
+	for i, s := range sources {
+		if *s != expected[i] {
+			t.Errorf("%d: %+v", i, *s)
+		}
+	}
+}
+
+func TestLogsRunnerGetSources_NoColor(t *testing.T) {
+	cfg := &config.Config{}
+	a := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	l := &logsRunner{
+		cfg: cfg,
+		opts: &Options{
+			NoColor: true,
+		},
+	}
+	sources := l.getSources([]*v1.Pod{newTestPod("a-env1")}, []*config.Service{a})
+	for _, s := range sources {
+		if s.color != 0 {
+			t.Fail()
+		}
+	}
+}
+
+func TestLogsRunnerNewPodLogOptions(t *testing.T) {
+	tail := int64(10)
+	l := &logsRunner{
+		opts: &Options{
+			Follow:     true,
+			Previous:   true,
+			Since:      90 * time.Second,
+			Tail:       &tail,
+			Timestamps: true,
+		},
+	}
+	podLogOptions := l.newPodLogOptions("main")
+	if podLogOptions.Container != "main" || !podLogOptions.Follow || !podLogOptions.Previous || !podLogOptions.Timestamps {
+		t.Fail()
+	}
+	if podLogOptions.TailLines != &tail {
+		t.Fail()
+	}
+	if podLogOptions.SinceSeconds == nil || *podLogOptions.SinceSeconds != 90 {
+		t.Fail()
+	}
+}
+
+func TestLogsRunnerNewPodLogOptions_Defaults(t *testing.T) {
+	l := &logsRunner{
+		opts: &Options{},
+	}
+	podLogOptions := l.newPodLogOptions("main")
+	if podLogOptions.TailLines != nil || podLogOptions.SinceSeconds != nil {
+		t.Fail()
+	}
+}
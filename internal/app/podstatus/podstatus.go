@@ -0,0 +1,86 @@
+package podstatus
+
+import (
+	"fmt"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+// Status is a summary of the status of a pod. Statuses are ordered such that pods progress from lower to higher statuses.
+type Status int
+
+const (
+	StatusReady           Status = 2
+	StatusStarted         Status = 1
+	StatusOther           Status = 0
+	StatusCompleted       Status = 3
+	StatusReadyString            = "ready"
+	StatusStartedString          = "started"
+	StatusCompletedString        = "completed"
+	StatusOtherString            = "other"
+)
+
+func (status *Status) String() string {
+	switch *status {
+	case StatusReady:
+		return StatusReadyString
+	case StatusStarted:
+		return StatusStartedString
+	case StatusCompleted:
+		return StatusCompletedString
+	}
+	return StatusOtherString
+}
+
+// IsPodReady returns true if and only if the pod has condition Ready.
+func IsPodReady(pod *v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady && condition.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// Parse returns the status of a pod. An error is returned if a container of the pod terminated abnormally or could not pull its image.
+func Parse(pod *v1.Pod) (Status, error) {
+	if IsPodReady(pod) {
+		return StatusReady, nil
+	}
+	runningCount := 0
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		t := containerStatus.State.Terminated
+		if t != nil {
+			return parseTerminatedContainer(pod.ObjectMeta.Name, containerStatus.Name, t)
+		}
+		if w := containerStatus.State.Waiting; w != nil && w.Reason == "ErrImagePull" {
+			return StatusOther, fmt.Errorf("container %s of pod %s could not pull image: %s",
+				containerStatus.Name,
+				pod.ObjectMeta.Name,
+				w.Message,
+			)
+		}
+		if containerStatus.State.Running != nil {
+			runningCount++
+		}
+	}
+	// A pending pod has no container statuses until it has been scheduled.
+	if runningCount > 0 && runningCount == len(pod.Status.ContainerStatuses) {
+		return StatusStarted, nil
+	}
+	return StatusOther, nil
+}
+
+func parseTerminatedContainer(podName, containerName string, t *v1.ContainerStateTerminated) (Status, error) {
+	if t.Reason != "Completed" {
+		return StatusOther, fmt.Errorf("container %s of pod %s terminated abnormally (code=%d,signal=%d,reason=%s): %s",
+			containerName,
+			podName,
+			t.ExitCode,
+			t.Signal,
+			t.Reason,
+			t.Message,
+		)
+	}
+	return StatusCompleted, nil
+}
@@ -0,0 +1,70 @@
+package podstatus
+
+import (
+	"testing"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestStatusString_Ready(t *testing.T) {
+	status := StatusReady
+	str := (&status).String()
+	if str != StatusReadyString {
+		t.Fail()
+	}
+}
+
+func TestStatusString_Started(t *testing.T) {
+	status := StatusStarted
+	str := (&status).String()
+	if str != StatusStartedString {
+		t.Fail()
+	}
+}
+
+func TestStatusString_Completed(t *testing.T) {
+	status := StatusCompleted
+	str := (&status).String()
+	if str != StatusCompletedString {
+		t.Fail()
+	}
+}
+
+func TestStatusString_Other(t *testing.T) {
+	status := Status(-1)
+	str := (&status).String()
+	if str != StatusOtherString {
+		t.Fail()
+	}
+}
+
+func TestParse_Pending(t *testing.T) {
+	pod := &v1.Pod{
+		Status: v1.PodStatus{
+			Phase: v1.PodPending,
+		},
+	}
+	status, err := Parse(pod)
+	if err != nil || status != StatusOther {
+		t.Error(status, err)
+	}
+}
+
+func TestParse_Started(t *testing.T) {
+	pod := &v1.Pod{
+		Status: v1.PodStatus{
+			Phase: v1.PodRunning,
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					State: v1.ContainerState{
+						Running: &v1.ContainerStateRunning{},
+					},
+				},
+			},
+		},
+	}
+	status, err := Parse(pod)
+	if err != nil || status != StatusStarted {
+		t.Error(status, err)
+	}
+}
@@ -0,0 +1,315 @@
+package portforward
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	k8swatch "k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/transport/spdy"
+)
+
+// How long to wait before trying to find a pod again, after no running pod was found or the connection to a pod was lost.
+var retryInterval = 2 * time.Second
+
+// Mapping is a port forward from a local address to a port of the pods of a docker compose service.
+type Mapping struct {
+	Host     string
+	Local    int32
+	Remote   int32
+	Service  string
+	Protocol string
+}
+
+// Options are the options of the port-forward command.
+type Options struct {
+	// The writer to which the mappings are written once they have been determined.
+	Out io.Writer
+	// The writer to which errors of individual connections are written.
+	ErrOut io.Writer
+	// Port forwarding stops when this channel is closed.
+	StopChannel <-chan struct{}
+}
+
+// forwarder forwards ports of one docker compose service bound to one host.
+type forwarder struct {
+	composeService *config.Service
+	host           string
+	mappings       []*Mapping
+}
+
+type portForwardRunner struct {
+	cfg          *config.Config
+	k8sClientset *kubernetes.Clientset
+	opts         *Options
+	transport    http.RoundTripper
+	upgrader     spdy.Upgrader
+}
+
+func (p *portForwardRunner) initKubernetesClientset() error {
+	k8sClientset, err := kubernetes.NewForConfig(p.cfg.KubeConfig)
+	if err != nil {
+		return err
+	}
+	p.k8sClientset = k8sClientset
+	p.transport, p.upgrader, err = spdy.RoundTripperFor(p.cfg.KubeConfig)
+	return err
+}
+
+// getListenHost returns the host that is used to check whether a port is free. The empty host is forwarded on localhost.
+func getListenHost(host string) string {
+	if host == "" {
+		return "127.0.0.1"
+	}
+	return host
+}
+
+// findFreePort returns the first port in the range [min, max] that can be listened on. If min is 0 then the operating system chooses a
+// port. If the range has a length of one then the port is returned without checking.
+func findFreePort(host string, min, max int32) (int32, error) {
+	if min == max && min != 0 {
+		return min, nil
+	}
+	for port := min; port <= max; port++ {
+		listener, err := net.Listen("tcp", net.JoinHostPort(getListenHost(host), strconv.Itoa(int(port))))
+		if err != nil {
+			continue
+		}
+		port = int32(listener.Addr().(*net.TCPAddr).Port)
+		err = listener.Close()
+		if err != nil {
+			return 0, err
+		}
+		return port, nil
+	}
+	return 0, fmt.Errorf("no free port in range %d-%d on host %#v", min, max, host)
+}
+
+// newForwarders returns a forwarder for each docker compose service that matches the filter directly and host of its published ports.
+// Kubernetes only supports forwarding TCP ports, so other ports are skipped.
+func newForwarders(cfg *config.Config) ([]*forwarder, error) {
+	var forwarders []*forwarder
+	for _, composeService := range cfg.Services {
+		if !cfg.MatchesFilterDirectly(composeService) {
+			continue
+		}
+		forwarderByHost := map[string]*forwarder{}
+		for _, portBinding := range composeService.DockerComposeService.Ports {
+			if portBinding.ExternalMin < 0 {
+				continue
+			}
+			if portBinding.Protocol != "tcp" {
+				log.Warnf("skipping port %d/%s of service %s, because only TCP ports can be forwarded", portBinding.Internal,
+					portBinding.Protocol, composeService.Name())
+				continue
+			}
+			local, err := findFreePort(portBinding.Host, portBinding.ExternalMin, portBinding.ExternalMax)
+			if err != nil {
+				return nil, err
+			}
+			f := forwarderByHost[portBinding.Host]
+			if f == nil {
+				f = &forwarder{
+					composeService: composeService,
+					host:           portBinding.Host,
+				}
+				forwarderByHost[portBinding.Host] = f
+				forwarders = append(forwarders, f)
+			}
+			f.mappings = append(f.mappings, &Mapping{
+				Host:     getListenHost(portBinding.Host),
+				Local:    local,
+				Remote:   portBinding.Internal,
+				Service:  composeService.Name(),
+				Protocol: portBinding.Protocol,
+			})
+		}
+	}
+	sort.Slice(forwarders, func(i, j int) bool {
+		if forwarders[i].composeService != forwarders[j].composeService {
+			return forwarders[i].composeService.Name() < forwarders[j].composeService.Name()
+		}
+		return forwarders[i].host < forwarders[j].host
+	})
+	return forwarders, nil
+}
+
+func (f *forwarder) addresses() []string {
+	if f.host == "" {
+		return []string{"localhost"}
+	}
+	return []string{f.host}
+}
+
+func (f *forwarder) ports() []string {
+	ports := make([]string, len(f.mappings))
+	for i, mapping := range f.mappings {
+		ports[i] = fmt.Sprintf("%d:%d", mapping.Local, mapping.Remote)
+	}
+	return ports
+}
+
+func (p *portForwardRunner) isStopped() bool {
+	select {
+	case <-p.opts.StopChannel:
+		return true
+	default:
+		return false
+	}
+}
+
+// stopWhenPodStops closes podStopChannel once the pod of the watch events is deleted or no longer running, the event channel is closed or
+// the stop channel of the options is closed.
+func (p *portForwardRunner) stopWhenPodStops(eventChannel <-chan k8swatch.Event, podStopChannel chan<- struct{}) {
+	defer close(podStopChannel)
+	for {
+		select {
+		case <-p.opts.StopChannel:
+			return
+		case event, ok := <-eventChannel:
+			if !ok {
+				return
+			}
+			switch event.Type {
+			case k8swatch.Added, k8swatch.Modified:
+				pod := event.Object.(*v1.Pod)
+				if pod.ObjectMeta.DeletionTimestamp != nil || pod.Status.Phase != v1.PodRunning {
+					log.Debugf("pod %s is no longer running", pod.ObjectMeta.Name)
+					return
+				}
+			default:
+				return
+			}
+		}
+	}
+}
+
+// forwardOnce forwards the ports of f to a running pod until the connection is lost, the pod is deleted or stops running, or the stop
+// channel is closed. Returns false if no running pod was found.
+func (p *portForwardRunner) forwardOnce(f *forwarder) (bool, error) {
+	podClient := p.k8sClientset.CoreV1().Pods(p.cfg.Namespace)
+	podList, err := podClient.List(metav1.ListOptions{
+		LabelSelector: p.cfg.EnvironmentLabel + "=" + p.cfg.EnvironmentID + ",app=" + f.composeService.NameEscaped,
+	})
+	if err != nil {
+		return false, err
+	}
+	pod := k8smeta.FindRunningPod(p.cfg, f.composeService, podList.Items)
+	if pod == nil {
+		return false, nil
+	}
+	// Port forwarding keeps working while a pod terminates, so the pod is watched to reconnect to its replacement.
+	watch, err := podClient.Watch(metav1.ListOptions{
+		FieldSelector:   fields.OneTermEqualSelector("metadata.name", pod.ObjectMeta.Name).String(),
+		ResourceVersion: podList.ResourceVersion,
+		Watch:           true,
+	})
+	if err != nil {
+		return false, err
+	}
+	defer watch.Stop()
+	podStopChannel := make(chan struct{})
+	go p.stopWhenPodStops(watch.ResultChan(), podStopChannel)
+	req := p.k8sClientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(p.cfg.Namespace).
+		Name(pod.ObjectMeta.Name).
+		SubResource("portforward")
+	dialer := spdy.NewDialer(p.upgrader, &http.Client{Transport: p.transport}, "POST", req.URL())
+	forwarder, err := portforward.NewOnAddresses(dialer, f.addresses(), f.ports(), podStopChannel, nil, nil, p.opts.ErrOut)
+	if err != nil {
+		return false, err
+	}
+	log.Debugf("forwarding ports of service %s to pod %s", f.composeService.Name(), pod.ObjectMeta.Name)
+	return true, forwarder.ForwardPorts()
+}
+
+// forward forwards the ports of f until the stop channel is closed, reconnecting when pods are recreated.
+func (p *portForwardRunner) forward(f *forwarder) {
+	waiting := false
+	for !p.isStopped() {
+		found, err := p.forwardOnce(f)
+		switch {
+		case err != nil:
+			log.Errorf("error while forwarding ports of service %s: %v", f.composeService.Name(), err)
+		case !found:
+			if !waiting {
+				log.Infof("waiting for a running pod of service %s", f.composeService.Name())
+			}
+		case !p.isStopped():
+			log.Infof("lost connection to the pod of service %s, reconnecting", f.composeService.Name())
+		}
+		waiting = err == nil && !found
+		select {
+		case <-p.opts.StopChannel:
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+func (p *portForwardRunner) run() error {
+	forwarders, err := newForwarders(p.cfg)
+	if err != nil {
+		return err
+	}
+	if len(forwarders) == 0 {
+		return fmt.Errorf("none of the services publish TCP ports")
+	}
+	err = p.initKubernetesClientset()
+	if err != nil {
+		return err
+	}
+	var mappings []*Mapping
+	for _, f := range forwarders {
+		mappings = append(mappings, f.mappings...)
+	}
+	writeMappings(p.opts.Out, mappings)
+	var wg sync.WaitGroup
+	for _, f := range forwarders {
+		wg.Add(1)
+		go func(f *forwarder) {
+			defer wg.Done()
+			p.forward(f)
+		}(f)
+	}
+	wg.Wait()
+	return nil
+}
+
+func writeMappings(w io.Writer, mappings []*Mapping) {
+	rows := [][]string{
+		{"SERVICE", "LOCAL", "REMOTE"},
+	}
+	for _, mapping := range mappings {
+		rows = append(rows, []string{
+			mapping.Service,
+			net.JoinHostPort(mapping.Host, strconv.Itoa(int(mapping.Local))),
+			fmt.Sprintf("%d/%s", mapping.Remote, mapping.Protocol),
+		})
+	}
+	fmt.Fprint(w, util.FormatTable(rows))
+}
+
+// Run forwards the published TCP ports of the docker compose services that match the filter of cfg directly to the pods of those docker
+// compose services, until the stop channel is closed.
+func Run(cfg *config.Config, opts *Options) error {
+	p := &portForwardRunner{
+		cfg:  cfg,
+		opts: opts,
+	}
+	return p.run()
+}
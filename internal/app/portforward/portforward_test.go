@@ -0,0 +1,183 @@
+package portforward
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8swatch "k8s.io/apimachinery/pkg/watch"
+)
+
+func TestFindFreePort_Single(t *testing.T) {
+	port, err := findFreePort("", 8080, 8080)
+	if err != nil || port != 8080 {
+		t.Fail()
+	}
+}
+
+func TestFindFreePort_Random(t *testing.T) {
+	port, err := findFreePort("", 0, 0)
+	if err != nil || port <= 0 {
+		t.Fail()
+	}
+}
+
+func TestFindFreePort_SkipsPortInUse(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	inUse := int32(listener.Addr().(*net.TCPAddr).Port)
+	port, err := findFreePort("127.0.0.1", inUse, inUse+1)
+	if err != nil {
+		// The next port may be in use as well.
+		t.Skip(err)
+	}
+	if port != inUse+1 {
+		t.Error(port)
+	}
+}
+
+func TestFindFreePort_NoFreePort(t *testing.T) {
+	_, err := findFreePort("256.0.0.1", 8000, 8001)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func newTestConfig() *config.Config {
+	cfg := &config.Config{}
+	a := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+		Ports: []dockerComposeConfig.PortBinding{
+			{Internal: 8234, ExternalMin: 8236, ExternalMax: 8236, Protocol: "tcp"},
+			{Internal: 53, ExternalMin: 5353, ExternalMax: 5353, Protocol: "udp"},
+			{Internal: 9000, ExternalMin: 9000, ExternalMax: 9000, Protocol: "tcp", Host: "127.0.0.2"},
+			{Internal: 8080, ExternalMin: -1, ExternalMax: -1, Protocol: "tcp"},
+		},
+	})
+	b := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "b",
+		Ports: []dockerComposeConfig.PortBinding{
+			{Internal: 80, ExternalMin: 8000, ExternalMax: 8000, Protocol: "tcp"},
+		},
+	})
+	cfg.AddService(&dockerComposeConfig.Service{
+		Name: "c",
+		Ports: []dockerComposeConfig.PortBinding{
+			{Internal: 80, ExternalMin: 8001, ExternalMax: 8001, Protocol: "tcp"},
+		},
+	})
+	cfg.AddToFilter(a)
+	cfg.AddToFilter(b)
+	return cfg
+}
+
+func TestNewForwarders(t *testing.T) {
+	cfg := newTestConfig()
+	forwarders, err := newForwarders(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(forwarders) != 3 {
+		t.Fatal(len(forwarders))
+	}
+	if forwarders[0].composeService.Name() != "a" || forwarders[0].host != "" {
+		t.Error(forwarders[0])
+	}
+	if ports := forwarders[0].ports(); len(ports) != 1 || ports[0] != "8236:8234" {
+		t.Error(ports)
+	}
+	if addresses := forwarders[0].addresses(); len(addresses) != 1 || addresses[0] != "localhost" {
+		t.Error(addresses)
+	}
+	if forwarders[1].composeService.Name() != "a" || forwarders[1].host != "127.0.0.2" {
+		t.Error(forwarders[1])
+	}
+	if addresses := forwarders[1].addresses(); len(addresses) != 1 || addresses[0] != "127.0.0.2" {
+		t.Error(addresses)
+	}
+	if forwarders[2].composeService.Name() != "b" {
+		t.Error(forwarders[2])
+	}
+}
+
+func TestWriteMappings(t *testing.T) {
+	var buffer bytes.Buffer
+	writeMappings(&buffer, []*Mapping{
+		{Host: "127.0.0.1", Local: 8236, Remote: 8234, Service: "a", Protocol: "tcp"},
+	})
+	expected := "SERVICE  LOCAL           REMOTE\n" +
+		"a        127.0.0.1:8236  8234/tcp\n"
+	if buffer.String() != expected {
+		t.Errorf("%#v", buffer.String())
+	}
+}
+
+func isClosed(channel <-chan struct{}) bool {
+	select {
+	case <-channel:
+		return true
+	case <-time.After(time.Second):
+		return false
+	}
+}
+
+func newTestPod(phase v1.PodPhase) *v1.Pod {
+	return &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "a-env1",
+		},
+		Status: v1.PodStatus{
+			Phase: phase,
+		},
+	}
+}
+
+func TestStopWhenPodStops_Deleted(t *testing.T) {
+	p := &portForwardRunner{
+		opts: &Options{},
+	}
+	eventChannel := make(chan k8swatch.Event, 2)
+	podStopChannel := make(chan struct{})
+	go p.stopWhenPodStops(eventChannel, podStopChannel)
+	eventChannel <- k8swatch.Event{Type: k8swatch.Modified, Object: newTestPod(v1.PodRunning)}
+	eventChannel <- k8swatch.Event{Type: k8swatch.Deleted, Object: newTestPod(v1.PodRunning)}
+	if !isClosed(podStopChannel) {
+		t.Fail()
+	}
+}
+
+func TestStopWhenPodStops_NotRunning(t *testing.T) {
+	p := &portForwardRunner{
+		opts: &Options{},
+	}
+	eventChannel := make(chan k8swatch.Event, 1)
+	podStopChannel := make(chan struct{})
+	go p.stopWhenPodStops(eventChannel, podStopChannel)
+	eventChannel <- k8swatch.Event{Type: k8swatch.Modified, Object: newTestPod(v1.PodFailed)}
+	if !isClosed(podStopChannel) {
+		t.Fail()
+	}
+}
+
+func TestStopWhenPodStops_Stopped(t *testing.T) {
+	stopChannel := make(chan struct{})
+	p := &portForwardRunner{
+		opts: &Options{
+			StopChannel: stopChannel,
+		},
+	}
+	podStopChannel := make(chan struct{})
+	go p.stopWhenPodStops(make(chan k8swatch.Event), podStopChannel)
+	close(stopChannel)
+	if !isClosed(podStopChannel) {
+		t.Fail()
+	}
+}
@@ -0,0 +1,139 @@
+package ps
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/app/podstatus"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// StatusError is the status of a pod of which a container terminated abnormally or could not pull its image.
+const StatusError = "error"
+
+// PodDetails describes a pod of a docker compose service.
+type PodDetails struct {
+	Service string `json:"service"`
+	Pod     string `json:"pod"`
+	Phase   string `json:"phase"`
+	// One of the strings of podstatus.Status, or StatusError.
+	Status   string `json:"status"`
+	Ready    bool   `json:"ready"`
+	Restarts int32  `json:"restarts"`
+	// The exit code of the most recently terminated container, or nil if no container of the pod has terminated.
+	ExitCode  *int32    `json:"exitCode,omitempty"`
+	Image     string    `json:"image"`
+	Ports     []string  `json:"ports"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type psRunner struct {
+	cfg          *config.Config
+	k8sClientset *kubernetes.Clientset
+	k8sPodClient clientV1.PodInterface
+}
+
+func (p *psRunner) initKubernetesClientset() error {
+	k8sClientset, err := kubernetes.NewForConfig(p.cfg.KubeConfig)
+	if err != nil {
+		return err
+	}
+	p.k8sClientset = k8sClientset
+	p.k8sPodClient = p.k8sClientset.CoreV1().Pods(p.cfg.Namespace)
+	return nil
+}
+
+// FormatPorts formats the ports of a docker compose service similar to docker-compose ps, e.g. 8236->8234/tcp for a published port.
+func FormatPorts(dcService *dockerComposeConfig.Service) []string {
+	var ports []string
+	for _, port := range dcService.Ports {
+		switch {
+		case port.ExternalMin < 0:
+			ports = append(ports, fmt.Sprintf("%d/%s", port.Internal, port.Protocol))
+		case port.ExternalMin == port.ExternalMax:
+			ports = append(ports, fmt.Sprintf("%d->%d/%s", port.ExternalMin, port.Internal, port.Protocol))
+		default:
+			ports = append(ports, fmt.Sprintf("%d-%d->%d/%s", port.ExternalMin, port.ExternalMax, port.Internal, port.Protocol))
+		}
+	}
+	for _, port := range dcService.Expose {
+		ports = append(ports, fmt.Sprintf("%d/%s", port.Internal, port.Protocol))
+	}
+	return ports
+}
+
+func newPodDetails(composeService *config.Service, pod *v1.Pod) *PodDetails {
+	d := &PodDetails{
+		Service:   composeService.Name(),
+		Pod:       pod.ObjectMeta.Name,
+		Phase:     string(pod.Status.Phase),
+		Ready:     podstatus.IsPodReady(pod),
+		Ports:     FormatPorts(composeService.DockerComposeService),
+		CreatedAt: pod.ObjectMeta.CreationTimestamp.Time,
+	}
+	status, err := podstatus.Parse(pod)
+	if err != nil {
+		d.Status = StatusError
+	} else {
+		d.Status = status.String()
+	}
+	if len(pod.Spec.Containers) > 0 {
+		d.Image = pod.Spec.Containers[0].Image
+	}
+	for _, containerStatus := range pod.Status.ContainerStatuses {
+		d.Restarts += containerStatus.RestartCount
+		terminated := containerStatus.State.Terminated
+		if terminated == nil {
+			terminated = containerStatus.LastTerminationState.Terminated
+		}
+		if terminated != nil {
+			exitCode := terminated.ExitCode
+			d.ExitCode = &exitCode
+		}
+	}
+	return d
+}
+
+func (p *psRunner) run() ([]*PodDetails, error) {
+	err := p.initKubernetesClientset()
+	if err != nil {
+		return nil, err
+	}
+	podList, err := p.k8sPodClient.List(metav1.ListOptions{
+		LabelSelector: p.cfg.EnvironmentLabel + "=" + p.cfg.EnvironmentID,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result []*PodDetails
+	for i := 0; i < len(podList.Items); i++ {
+		pod := &podList.Items[i]
+		composeService := k8smeta.FindFromObjectMeta(p.cfg, &pod.ObjectMeta)
+		if composeService == nil || !p.cfg.MatchesFilterDirectly(composeService) {
+			continue
+		}
+		result = append(result, newPodDetails(composeService, pod))
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Service != result[j].Service {
+			return result[i].Service < result[j].Service
+		}
+		return result[i].Pod < result[j].Pod
+	})
+	return result, nil
+}
+
+// Run returns the pods of the docker compose services that match the filter of cfg directly, ordered by service and pod name.
+func Run(cfg *config.Config) ([]*PodDetails, error) {
+	p := &psRunner{
+		cfg: cfg,
+	}
+	return p.run()
+}
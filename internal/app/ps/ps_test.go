@@ -0,0 +1,90 @@
+package ps
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestFormatPorts(t *testing.T) {
+	dcService := &dockerComposeConfig.Service{
+		Ports: []dockerComposeConfig.PortBinding{
+			{Internal: 8234, ExternalMin: 8236, ExternalMax: 8236, Protocol: "tcp"},
+			{Internal: 80, ExternalMin: 8000, ExternalMax: 8010, Protocol: "tcp"},
+			{Internal: 8080, ExternalMin: -1, ExternalMax: -1, Protocol: "tcp"},
+		},
+		Expose: []dockerComposeConfig.PortBinding{
+			{Internal: 53, ExternalMin: -1, ExternalMax: -1, Protocol: "udp"},
+		},
+	}
+	ports := FormatPorts(dcService)
+	expected := []string{"8236->8234/tcp", "8000-8010->80/tcp", "8080/tcp", "53/udp"}
+	if !reflect.DeepEqual(ports, expected) {
+		t.Error(ports)
+	}
+}
+
+func TestNewPodDetails_Completed(t *testing.T) {
+	cfg := &config.Config{}
+	composeService := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "a-env1",
+		},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{Image: "ubuntu:latest"},
+			},
+		},
+		Status: v1.PodStatus{
+			Phase: v1.PodSucceeded,
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					RestartCount: 2,
+					State: v1.ContainerState{
+						Terminated: &v1.ContainerStateTerminated{
+							ExitCode: 0,
+							Reason:   "Completed",
+						},
+					},
+				},
+			},
+		},
+	}
+	d := newPodDetails(composeService, pod)
+	if d.Service != "a" || d.Pod != "a-env1" || d.Phase != "Succeeded" || d.Status != "completed" || d.Ready || d.Restarts != 2 ||
+		d.ExitCode == nil || *d.ExitCode != 0 || d.Image != "ubuntu:latest" {
+		t.Error(d)
+	}
+}
+
+func TestNewPodDetails_Error(t *testing.T) {
+	cfg := &config.Config{}
+	composeService := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	pod := &v1.Pod{
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					State: v1.ContainerState{
+						Terminated: &v1.ContainerStateTerminated{
+							ExitCode: 137,
+							Reason:   "Error",
+						},
+					},
+				},
+			},
+		},
+	}
+	d := newPodDetails(composeService, pod)
+	if d.Status != StatusError || d.ExitCode == nil || *d.ExitCode != 137 {
+		t.Error(d)
+	}
+}
@@ -0,0 +1,91 @@
+package registry
+
+import (
+	"fmt"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+)
+
+// DefaultPullHost is the host of the OpenShift integrated docker registry as seen by pods.
+const DefaultPullHost = "docker-registry.default.svc:5000"
+
+// DefaultRepository is the default template of the name of the repository of a docker compose service.
+const DefaultRepository = "{{.Namespace}}/{{.Service}}"
+
+// GetRepository returns the name of the repository that the images of a docker compose service are pushed to. The cluster image storage
+// must be a docker registry.
+func GetRepository(cfg *config.Config, composeService *config.Service) (string, error) {
+	repositoryTemplate := cfg.ClusterImageStorage.DockerRegistry.Repository
+	if repositoryTemplate == "" {
+		repositoryTemplate = DefaultRepository
+	}
+	return config.ExecuteRepositoryTemplate(repositoryTemplate, &config.RepositoryTemplateData{
+		EnvironmentID: cfg.EnvironmentID,
+		Namespace:     cfg.Namespace,
+		Service:       composeService.NameEscaped,
+	})
+}
+
+// GetPushImage returns the reference that an image of a docker compose service is pushed to.
+func GetPushImage(cfg *config.Config, composeService *config.Service, tag string) (string, error) {
+	repository, err := GetRepository(cfg, composeService)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s:%s", cfg.ClusterImageStorage.DockerRegistry.Host, repository, tag), nil
+}
+
+// GetPodImage returns the reference of a pushed image of a docker compose service as seen by the cluster.
+func GetPodImage(cfg *config.Config, composeService *config.Service, digest string) (string, error) {
+	repository, err := GetRepository(cfg, composeService)
+	if err != nil {
+		return "", err
+	}
+	pullHost := cfg.ClusterImageStorage.DockerRegistry.PullHost
+	if pullHost == "" {
+		pullHost = DefaultPullHost
+	}
+	return fmt.Sprintf("%s/%s@%s", pullHost, repository, digest), nil
+}
+
+// GetAuthConfig returns the credentials of the docker registry, according to the auth setting of the cluster image storage.
+func GetAuthConfig(cfg *config.Config) (*dockerTypes.AuthConfig, error) {
+	dockerRegistry := cfg.ClusterImageStorage.DockerRegistry
+	switch dockerRegistry.Auth {
+	case config.RegistryAuthNone:
+		return &dockerTypes.AuthConfig{}, nil
+	case config.RegistryAuthDockerConfig:
+		configFile, err := docker.LoadDefaultConfigFile()
+		if err != nil {
+			return nil, err
+		}
+		return configFile.GetAuthConfig(dockerRegistry.Host)
+	}
+	return &dockerTypes.AuthConfig{
+		Username: "unused",
+		Password: cfg.KubeConfig.BearerToken,
+	}, nil
+}
+
+// NewClient returns a client of the docker registry, for requests that kube-compose makes directly instead of through the docker daemon.
+func NewClient(cfg *config.Config) (*docker.Registry, error) {
+	dockerRegistry := cfg.ClusterImageStorage.DockerRegistry
+	authConfig, err := GetAuthConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	httpClient, err := docker.NewHTTPClient(dockerRegistry.Insecure, dockerRegistry.CAFile)
+	if err != nil {
+		return nil, err
+	}
+	return &docker.Registry{
+		Client:        httpClient,
+		IdentityToken: authConfig.IdentityToken,
+		Insecure:      dockerRegistry.Insecure,
+		Password:      authConfig.Password,
+		URL:           "https://" + dockerRegistry.Host,
+		Username:      authConfig.Username,
+	}, nil
+}
@@ -0,0 +1,81 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	"k8s.io/client-go/rest"
+)
+
+func newTestConfig() (*config.Config, *config.Service) {
+	cfg := &config.Config{
+		EnvironmentID: "env1",
+		KubeConfig: &rest.Config{
+			BearerToken: "token1",
+		},
+		Namespace: "ns1",
+	}
+	cfg.ClusterImageStorage.DockerRegistry = &config.DockerRegistryClusterImageStorage{
+		Host: "registry.example.com",
+	}
+	composeService := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	return cfg, composeService
+}
+
+func TestGetPushImage_Default(t *testing.T) {
+	cfg, composeService := newTestConfig()
+	actual, err := GetPushImage(cfg, composeService, "env1-main")
+	if err != nil || actual != "registry.example.com/ns1/a:env1-main" {
+		t.Error(actual, err)
+	}
+}
+
+func TestGetPodImage_Default(t *testing.T) {
+	cfg, composeService := newTestConfig()
+	actual, err := GetPodImage(cfg, composeService, "sha256:1")
+	if err != nil || actual != "docker-registry.default.svc:5000/ns1/a@sha256:1" {
+		t.Error(actual, err)
+	}
+}
+
+func TestGetPodImage_PullHostAndRepository(t *testing.T) {
+	cfg, composeService := newTestConfig()
+	cfg.ClusterImageStorage.DockerRegistry.PullHost = "registry.internal:5000"
+	cfg.ClusterImageStorage.DockerRegistry.Repository = "team/{{.EnvironmentID}}-{{.Service}}"
+	actual, err := GetPodImage(cfg, composeService, "sha256:1")
+	if err != nil || actual != "registry.internal:5000/team/env1-a@sha256:1" {
+		t.Error(actual, err)
+	}
+}
+
+func TestGetAuthConfig_KubeBearerToken(t *testing.T) {
+	cfg, _ := newTestConfig()
+	authConfig, err := GetAuthConfig(cfg)
+	if err != nil || authConfig.Username != "unused" || authConfig.Password != "token1" {
+		t.Error(authConfig, err)
+	}
+}
+
+func TestGetAuthConfig_None(t *testing.T) {
+	cfg, _ := newTestConfig()
+	cfg.ClusterImageStorage.DockerRegistry.Auth = config.RegistryAuthNone
+	authConfig, err := GetAuthConfig(cfg)
+	if err != nil || authConfig.Username != "" || authConfig.Password != "" {
+		t.Error(authConfig, err)
+	}
+}
+
+func TestNewClient_Success(t *testing.T) {
+	cfg, _ := newTestConfig()
+	cfg.ClusterImageStorage.DockerRegistry.Insecure = true
+	registryClient, err := NewClient(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if registryClient.URL != "https://registry.example.com" || !registryClient.Insecure || registryClient.Password != "token1" {
+		t.Error(registryClient)
+	}
+}
@@ -0,0 +1,93 @@
+package up
+
+import (
+	"encoding/json"
+	"fmt"
+
+	log "github.com/Sirupsen/logrus"
+	dockerRef "github.com/docker/distribution/reference"
+	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+	"github.com/pkg/errors"
+)
+
+// isDaemonless returns true if images are inspected through the registry API instead of the local docker daemon. The local docker daemon
+// is still required if images are tagged or pushed for the cluster image storage.
+func (u *upRunner) isDaemonless() bool {
+	return u.opts.Daemonless && u.cfg.ClusterImageStorage.Docker == nil && u.cfg.ClusterImageStorage.DockerRegistry == nil
+}
+
+// warnIfNotDaemonless warns if daemonless mode was requested but is disabled because the cluster image storage is configured.
+func (u *upRunner) warnIfNotDaemonless() {
+	if u.opts.Daemonless && !u.isDaemonless() {
+		log.Warn("ignoring --daemonless, because images are pulled through the docker daemon to tag or push them for the " +
+			"cluster_image_storage")
+	}
+}
+
+// newRemoteRegistry returns a client of the registry of a host, with the credentials of the docker config file.
+func (u *upRunner) newRemoteRegistry(host string) (*docker.Registry, error) {
+	authConfig, err := u.getDockerConfigAuthConfig(host)
+	if err != nil {
+		return nil, err
+	}
+	return &docker.Registry{
+		IdentityToken: authConfig.IdentityToken,
+		Password:      authConfig.Password,
+		URL:           docker.GetRegistryURL(host),
+		Username:      authConfig.Username,
+	}, nil
+}
+
+// getAppImageInfoFromRegistry is like getAppImageInfo, but gets the configuration of the image from the registry of the image instead of
+// pulling the image. The pod image references the image by digest, so that pods run the image that was inspected.
+func (u *upRunner) getAppImageInfoFromRegistry(a *app, sourceImage string) error {
+	named, err := dockerRef.ParseNormalizedNamed(sourceImage)
+	if err != nil {
+		return errors.Wrapf(err, "error while parsing image %#v (images must be named references in daemonless mode)", sourceImage)
+	}
+	named = dockerRef.TagNameOnly(named)
+	var reference string
+	if digested, ok := named.(dockerRef.Digested); ok {
+		reference = digested.Digest().String()
+	} else {
+		reference = named.(dockerRef.Tagged).Tag()
+	}
+	registry, err := u.newRemoteRegistry(dockerRef.Domain(named))
+	if err != nil {
+		return err
+	}
+	pt := a.reporterRow.AddProgressTask("inspecting image")
+	image, err := registry.GetImage(u.opts.Context, dockerRef.Path(named), reference)
+	pt.Done()
+	if err != nil {
+		return errors.Wrapf(err, "error while inspecting image %#v", sourceImage)
+	}
+	var imageConfig struct {
+		Config struct {
+			Cmd  []string `json:"Cmd"`
+			User string   `json:"User"`
+		} `json:"config"`
+	}
+	err = json.Unmarshal(image.Config, &imageConfig)
+	if err != nil {
+		return errors.Wrapf(err, "image %#v has an invalid configuration", sourceImage)
+	}
+	a.imageInfo.sourceImageID = image.ID
+	a.imageInfo.podImage = named.Name() + "@" + image.Digest
+	a.imageInfo.cmd = imageConfig.Config.Cmd
+	a.imageInfo.imageUser = imageConfig.Config.User
+	a.imageInfo.imageHealthcheck, err = inspectImageRawParseHealthcheck(image.Config)
+	if err != nil {
+		return err
+	}
+	if u.opts.RunAsUser {
+		return u.getAppImageInfoUser(a, imageConfig.Config.User, sourceImage)
+	}
+	return nil
+}
+
+// errorDaemonlessUserinfo returns the error of getAppImageInfoUser if the uid/gid of a user can only be determined by running the image.
+func errorDaemonlessUserinfo(sourceImage string) error {
+	return fmt.Errorf("the uid/gid of the user of image %#v cannot be determined in daemonless mode, because the user is not numeric",
+		sourceImage)
+}
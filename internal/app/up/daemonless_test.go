@@ -0,0 +1,128 @@
+package up
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+)
+
+const testDaemonlessImageConfig = `{"config":{"Cmd":["sh"],"User":"user1",` +
+	`"Healthcheck":{"Test":["CMD","true"],"Interval":1000000000}}}`
+
+func newTestDaemonlessRegistryServer() (*httptest.Server, string) {
+	configDigest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(testDaemonlessImageConfig)))
+	manifest := `{"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"digest":"` + configDigest + `"}}`
+	manifestDigest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(manifest)))
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/ns/a/manifests/latest":
+			_, _ = w.Write([]byte(manifest))
+		case "/v2/ns/a/blobs/" + configDigest:
+			_, _ = w.Write([]byte(testDaemonlessImageConfig))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server, manifestDigest
+}
+
+// withTestDaemonlessRegistryServer runs the callback with a registry server that is trusted by the default HTTP client.
+func withTestDaemonlessRegistryServer(cb func(host, manifestDigest string)) {
+	server, manifestDigest := newTestDaemonlessRegistryServer()
+	defer server.Close()
+	orig := http.DefaultClient
+	defer func() {
+		http.DefaultClient = orig
+	}()
+	http.DefaultClient = server.Client()
+	cb(strings.TrimPrefix(server.URL, "https://"), manifestDigest)
+}
+
+func newTestDaemonlessRunner(image string) (*upRunner, *app) {
+	cfg := &config.Config{}
+	a := &app{
+		composeService: cfg.AddService(&dockerComposeConfig.Service{
+			Image: image,
+			Name:  "a",
+		}),
+		reporterRow: reporter.New(ioutil.Discard).AddRow("a"),
+	}
+	u := &upRunner{
+		cfg: cfg,
+		opts: &Options{
+			Context:    context.Background(),
+			Daemonless: true,
+		},
+	}
+	return u, a
+}
+
+func TestGetAppImageInfoFromRegistry_Success(t *testing.T) {
+	withTestDaemonlessRegistryServer(func(host, manifestDigest string) {
+		u, a := newTestDaemonlessRunner(host + "/ns/a")
+		err := u.getAppImageInfoFromRegistry(a, a.composeService.DockerComposeService.Image)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.imageInfo.podImage != host+"/ns/a@"+manifestDigest {
+			t.Error(a.imageInfo.podImage)
+		}
+		if a.imageInfo.imageUser != "user1" || len(a.imageInfo.cmd) != 1 || a.imageInfo.cmd[0] != "sh" {
+			t.Error(a.imageInfo)
+		}
+		if a.imageInfo.imageHealthcheck == nil || a.imageInfo.imageHealthcheck.Test[0] != "true" {
+			t.Error(a.imageInfo.imageHealthcheck)
+		}
+	})
+}
+
+func TestGetAppImageInfoFromRegistry_RunAsUserNotNumeric(t *testing.T) {
+	withTestDaemonlessRegistryServer(func(host, manifestDigest string) {
+		u, a := newTestDaemonlessRunner(host + "/ns/a:latest")
+		u.opts.RunAsUser = true
+		err := u.getAppImageInfoFromRegistry(a, a.composeService.DockerComposeService.Image)
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestGetAppImageInfoFromRegistry_NotFound(t *testing.T) {
+	withTestDaemonlessRegistryServer(func(host, manifestDigest string) {
+		u, a := newTestDaemonlessRunner(host + "/ns/b:latest")
+		err := u.getAppImageInfoFromRegistry(a, a.composeService.DockerComposeService.Image)
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestGetAppImageInfoFromRegistry_InvalidImage(t *testing.T) {
+	u, a := newTestDaemonlessRunner("")
+	err := u.getAppImageInfoFromRegistry(a, "sha256:1234")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestIsDaemonless(t *testing.T) {
+	u, _ := newTestDaemonlessRunner("ubuntu")
+	if !u.isDaemonless() {
+		t.Fail()
+	}
+	u.cfg.ClusterImageStorage.DockerRegistry = &config.DockerRegistryClusterImageStorage{
+		Host: "registry.example.com",
+	}
+	if u.isDaemonless() {
+		t.Fail()
+	}
+}
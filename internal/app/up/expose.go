@@ -0,0 +1,143 @@
+package up
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	extensionsV1beta1 "k8s.io/api/extensions/v1beta1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// The range of ports that Kubernetes allocates node ports from by default.
+const (
+	nodePortMin = 30000
+	nodePortMax = 32767
+)
+
+// serviceType returns the type of the Kubernetes Service of the app, based on how the app's published ports are exposed.
+func (a *app) serviceType() v1.ServiceType {
+	if a.composeService.Expose == nil || !a.hasClusterIP() {
+		return v1.ServiceTypeClusterIP
+	}
+	switch a.composeService.Expose.Type {
+	case config.ExposeTypeNodePort:
+		return v1.ServiceTypeNodePort
+	case config.ExposeTypeLoadBalancer:
+		return v1.ServiceTypeLoadBalancer
+	}
+	return v1.ServiceTypeClusterIP
+}
+
+func isPublished(port *dockerComposeConfig.PortBinding) bool {
+	return port.ExternalMin >= 0
+}
+
+func isNodePortInRange(port int32) bool {
+	return nodePortMin <= port && port <= nodePortMax
+}
+
+// newIngressRule returns the rule of an Ingress that routes requests for host to the internal port of the app's Kubernetes Service.
+func (u *upRunner) newIngressRule(app *app, host string, internal int32) extensionsV1beta1.IngressRule {
+	return extensionsV1beta1.IngressRule{
+		Host: host,
+		IngressRuleValue: extensionsV1beta1.IngressRuleValue{
+			HTTP: &extensionsV1beta1.HTTPIngressRuleValue{
+				Paths: []extensionsV1beta1.HTTPIngressPath{
+					{
+						Backend: extensionsV1beta1.IngressBackend{
+							ServiceName: k8smeta.GetK8sServiceName(app.composeService, u.cfg),
+							ServicePort: intstr.FromInt(int(internal)),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// newIngress returns an Ingress with one rule per published TCP port of the app, or nil if the app's published ports are not exposed
+// through an Ingress. Like the ports of the Kubernetes Service, port ranges are expanded into a rule per published port.
+func (u *upRunner) newIngress(app *app) (*extensionsV1beta1.Ingress, error) {
+	expose := app.composeService.Expose
+	if expose == nil || expose.Type != config.ExposeTypeIngress {
+		return nil, nil
+	}
+	ingress := &extensionsV1beta1.Ingress{}
+	k8smeta.InitObjectMeta(u.cfg, &ingress.ObjectMeta, app.composeService)
+	for i := range app.composeService.DockerComposeService.Ports {
+		port := &app.composeService.DockerComposeService.Ports[i]
+		if !isPublished(port) || port.Protocol != "tcp" {
+			continue
+		}
+		for external := port.ExternalMin; external <= port.ExternalMax; external++ {
+			host, err := u.cfg.FormatIngressHost(expose, app.composeService, external)
+			if err != nil {
+				return nil, err
+			}
+			ingress.Spec.Rules = append(ingress.Spec.Rules, u.newIngressRule(app, host, port.Internal))
+		}
+	}
+	if len(ingress.Spec.Rules) == 0 {
+		app.newLogEntry().Warnf("service is exposed through an ingress, but has no published tcp ports")
+		return nil, nil
+	}
+	return ingress, nil
+}
+
+// newIngresses returns the Ingresses of the apps that have a cluster IP, keyed by app. Because an Ingress controller routes all requests
+// for a host to one backend, it is an error if the hosts of two rules are equal (e.g. if ingress_host does not reference .Port, or if the
+// escaped names of two docker compose services are equal).
+func (u *upRunner) newIngresses() (map[*app]*extensionsV1beta1.Ingress, error) {
+	names := make([]string, 0, len(u.apps))
+	for name := range u.apps {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	ingresses := map[*app]*extensionsV1beta1.Ingress{}
+	hosts := map[string]*app{}
+	for _, name := range names {
+		app := u.apps[name]
+		if !app.hasClusterIP() {
+			continue
+		}
+		ingress, err := u.newIngress(app)
+		if err != nil {
+			return nil, err
+		}
+		if ingress == nil {
+			continue
+		}
+		for _, rule := range ingress.Spec.Rules {
+			if other := hosts[rule.Host]; other != nil {
+				return nil, fmt.Errorf("the ingress host %s is formatted for more than one published port (of services %s and %s); "+
+					"ingress_host must be unique for every service and published port, for example by referencing .Service and .Port",
+					rule.Host, other.name(), app.name())
+			}
+			hosts[rule.Host] = app
+		}
+		ingresses[app] = ingress
+	}
+	return ingresses, nil
+}
+
+// createIngress creates the Ingress of the app (see newIngresses), if the app's published ports are exposed through an Ingress.
+func (u *upRunner) createIngress(app *app, ingress *extensionsV1beta1.Ingress) error {
+	if ingress == nil {
+		return nil
+	}
+	_, err := u.k8sIngressClient.Create(ingress)
+	switch {
+	case k8sError.IsAlreadyExists(err):
+		app.newLogEntry().Debugf("ingress %s already exists", ingress.ObjectMeta.Name)
+	case err != nil:
+		return err
+	default:
+		app.newLogEntry().Infof("created ingress %s", ingress.ObjectMeta.Name)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package up
+
+import (
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+func TestAppServiceType(t *testing.T) {
+	app := newTestApp("a")
+	app.composeService.Expose = &config.Expose{Type: config.ExposeTypeNodePort}
+	if app.serviceType() != v1.ServiceTypeClusterIP {
+		// Headless services are always of type ClusterIP.
+		t.Fail()
+	}
+	app.composeService.Ports = []config.Port{
+		{
+			Port:     80,
+			Protocol: "tcp",
+		},
+	}
+	app.composeService.Expose = nil
+	if app.serviceType() != v1.ServiceTypeClusterIP {
+		t.Fail()
+	}
+	app.composeService.Expose = &config.Expose{Type: config.ExposeTypeNodePort}
+	if app.serviceType() != v1.ServiceTypeNodePort {
+		t.Fail()
+	}
+	app.composeService.Expose = &config.Expose{Type: config.ExposeTypeLoadBalancer}
+	if app.serviceType() != v1.ServiceTypeLoadBalancer {
+		t.Fail()
+	}
+	app.composeService.Expose = &config.Expose{Type: config.ExposeTypeIngress}
+	if app.serviceType() != v1.ServiceTypeClusterIP {
+		t.Fail()
+	}
+}
+
+func newTestIngressUpRunner(ingressHost string, ports ...dockerComposeConfig.PortBinding) *upRunner {
+	cfg := &config.Config{
+		EnvironmentID: "env1",
+		Expose: &config.Expose{
+			Type:        config.ExposeTypeIngress,
+			IngressHost: template.Must(template.New("ingress_host").Parse(ingressHost)),
+		},
+	}
+	u := &upRunner{
+		cfg:  cfg,
+		apps: map[string]*app{},
+	}
+	for _, name := range []string{"web", "api"} {
+		service := cfg.AddService(&dockerComposeConfig.Service{
+			Name:  name,
+			Ports: ports,
+		})
+		service.Ports = []config.Port{
+			{
+				Port:     80,
+				Protocol: "tcp",
+			},
+		}
+		u.apps[name] = &app{
+			composeService: service,
+		}
+	}
+	return u
+}
+
+func TestUpRunnerNewIngresses_ExpandsRange(t *testing.T) {
+	u := newTestIngressUpRunner("{{.Service}}-{{.Port}}.example.com",
+		dockerComposeConfig.PortBinding{Internal: 80, ExternalMin: 8080, ExternalMax: 8081, Protocol: "tcp"},
+		dockerComposeConfig.PortBinding{Internal: 53, ExternalMin: 53, ExternalMax: 53, Protocol: "udp"},
+	)
+	ingresses, err := u.newIngresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := ingresses[u.apps["web"]].Spec.Rules
+	if len(rules) != 2 || rules[0].Host != "web-8080.example.com" || rules[1].Host != "web-8081.example.com" {
+		t.Fatalf("%+v", rules)
+	}
+	for _, rule := range rules {
+		if rule.HTTP.Paths[0].Backend.ServicePort.IntValue() != 80 {
+			t.Error(rule.HTTP.Paths[0].Backend)
+		}
+	}
+	if len(ingresses[u.apps["api"]].Spec.Rules) != 2 {
+		t.Fail()
+	}
+}
+
+func TestUpRunnerNewIngresses_DuplicateHostOfPorts(t *testing.T) {
+	u := newTestIngressUpRunner("{{.Service}}.example.com",
+		dockerComposeConfig.PortBinding{Internal: 80, ExternalMin: 8080, ExternalMax: 8081, Protocol: "tcp"},
+	)
+	_, err := u.newIngresses()
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestUpRunnerNewIngresses_DuplicateHostOfServices(t *testing.T) {
+	u := newTestIngressUpRunner("app-{{.Port}}.example.com",
+		dockerComposeConfig.PortBinding{Internal: 80, ExternalMin: 8080, ExternalMax: 8080, Protocol: "tcp"},
+	)
+	_, err := u.newIngresses()
+	if err == nil || !strings.Contains(err.Error(), "of services api and web") {
+		t.Error(err)
+	}
+}
+
+func TestUpRunnerNewIngresses_NotExposed(t *testing.T) {
+	u := newTestIngressUpRunner("{{.Service}}.example.com",
+		dockerComposeConfig.PortBinding{Internal: 80, ExternalMin: 8080, ExternalMax: 8080, Protocol: "tcp"},
+	)
+	u.cfg.Services["api"].Expose = nil
+	ingresses, err := u.newIngresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := ingresses[u.apps["api"]]; ok || ingresses[u.apps["web"]] == nil {
+		t.Fail()
+	}
+}
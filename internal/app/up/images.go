@@ -0,0 +1,61 @@
+package up
+
+import (
+	"sync"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+)
+
+// runImages runs the image pipeline of up for the docker compose services that match the filter, without creating any Kubernetes
+// resources.
+func (u *upRunner) runImages() (*Lock, error) {
+	u.initApps()
+	u.initAppsToBeStarted()
+	u.warnIfNotDaemonless()
+	err := u.initDockerClient()
+	if err != nil {
+		return nil, err
+	}
+	var wg sync.WaitGroup
+	for a := range u.appsToBeStarted {
+		wg.Add(1)
+		go func(a *app) {
+			defer wg.Done()
+			// The error is returned below.
+			// nolint
+			u.getAppImageInfoOnce(a)
+		}(a)
+	}
+	wg.Wait()
+	for a := range u.appsToBeStarted {
+		if a.imageInfo.err != nil {
+			return nil, a.imageInfo.err
+		}
+	}
+	return u.newLock(), nil
+}
+
+func newImagesRunner(cfg *config.Config, opts *Options) *upRunner {
+	u := &upRunner{
+		cfg:  cfg,
+		opts: opts,
+	}
+	u.hostAliases.once = &sync.Once{}
+	u.localImagesCache.once = &sync.Once{}
+	return u
+}
+
+// Pull pulls the images of the docker compose services that match the filter (and tags them if the cluster image storage is docker), the
+// same way as Run. Images are not pushed, so if the cluster image storage is a docker registry then Run pushes the images of the returned
+// lock by their repo digest.
+func Pull(cfg *config.Config, opts *Options) (*Lock, error) {
+	u := newImagesRunner(cfg, opts)
+	u.skipPush = true
+	return u.runImages()
+}
+
+// Push pulls the images of the docker compose services that match the filter and pushes them to the cluster image storage, the same way
+// as Run.
+func Push(cfg *config.Config, opts *Options) (*Lock, error) {
+	return newImagesRunner(cfg, opts).runImages()
+}
@@ -0,0 +1,173 @@
+package up
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+
+	dockerRef "github.com/docker/distribution/reference"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+)
+
+// Lock is the content of a lock file, which is written by the pull and push commands. The lock file records the images of docker compose
+// services as resolved by the image pipeline of up, so that a later up can skip the pipeline.
+type Lock struct {
+	// Images pushed to a cluster image storage are specific to the environment and namespace.
+	EnvironmentID string                  `json:"environmentID"`
+	Namespace     string                  `json:"namespace"`
+	Services      map[string]*LockedImage `json:"services"`
+}
+
+// LockedImage is the resolved image of a docker compose service.
+type LockedImage struct {
+	// The Cmd of the image's config.
+	Cmd         []string                         `json:"cmd,omitempty"`
+	Healthcheck *dockerComposeConfig.Healthcheck `json:"healthcheck,omitempty"`
+	// The image of the docker compose service. An entry is only used if this equals the image in the docker compose file.
+	Image string `json:"image"`
+	// The image of the pod. Empty if the image has not been pushed to the cluster image storage yet, in which case up pushes the image of
+	// SourceRepoDigest.
+	PodImage           string        `json:"podImage,omitempty"`
+	PodImagePullPolicy v1.PullPolicy `json:"podImagePullPolicy,omitempty"`
+	SourceImageID      string        `json:"sourceImageID"`
+	// The repo digest of the source image (e.g. ubuntu@sha256:...). An entry without a pod image is ignored if this is empty.
+	SourceRepoDigest string `json:"sourceRepoDigest,omitempty"`
+	// The User of the image's config.
+	User string `json:"user,omitempty"`
+}
+
+// ReadLock decodes a lock written by Write.
+func ReadLock(r io.Reader) (*Lock, error) {
+	lock := &Lock{}
+	err := json.NewDecoder(r).Decode(lock)
+	if err != nil {
+		return nil, err
+	}
+	return lock, nil
+}
+
+// ReadLockFile reads a lock file written by WriteFile.
+func ReadLockFile(file string) (*Lock, error) {
+	fd, err := fs.OS.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseAndLogError(fd)
+	lock, err := ReadLock(fd)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading lock file %#v", file)
+	}
+	return lock, nil
+}
+
+// Write encodes the lock as indented JSON.
+func (l *Lock) Write(w io.Writer) error {
+	data, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// WriteFile writes the lock to a file.
+func (l *Lock) WriteFile(file string) error {
+	var buf bytes.Buffer
+	err := l.Write(&buf)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(file, buf.Bytes(), 0644)
+}
+
+// findLockedImage returns the locked image of the app, or nil if there is no lock file or the lock file has no usable entry for the app.
+func (u *upRunner) findLockedImage(a *app) *LockedImage {
+	if u.opts.Lock == nil {
+		return nil
+	}
+	lockedImage := u.opts.Lock.Services[a.name()]
+	if lockedImage == nil || (lockedImage.PodImage == "" && lockedImage.SourceRepoDigest == "") {
+		return nil
+	}
+	if u.opts.Lock.EnvironmentID != u.cfg.EnvironmentID || u.opts.Lock.Namespace != u.cfg.Namespace {
+		a.newLogEntry().Warnf("ignoring lock file, because it was written for environment %s in namespace %s",
+			u.opts.Lock.EnvironmentID, u.opts.Lock.Namespace)
+		return nil
+	}
+	if lockedImage.Image != a.composeService.DockerComposeService.Image {
+		a.newLogEntry().Warnf("ignoring lock file, because it was written for image %#v", lockedImage.Image)
+		return nil
+	}
+	return lockedImage
+}
+
+func (u *upRunner) getAppImageInfoFromLock(a *app, lockedImage *LockedImage) error {
+	a.newLogEntry().Debugf("using image %s from lock file", lockedImage.PodImage)
+	a.imageInfo.cmd = lockedImage.Cmd
+	a.imageInfo.imageHealthcheck = lockedImage.Healthcheck
+	a.imageInfo.imageUser = lockedImage.User
+	a.imageInfo.podImage = lockedImage.PodImage
+	a.imageInfo.podImagePullPolicy = lockedImage.PodImagePullPolicy
+	a.imageInfo.sourceImageID = lockedImage.SourceImageID
+	a.imageInfo.sourceRepoDigest = lockedImage.SourceRepoDigest
+	if u.opts.RunAsUser {
+		return u.getAppImageInfoUser(a, lockedImage.User, lockedImage.Image)
+	}
+	return nil
+}
+
+// getAppImageInfoFromLockedSourceImage uses an entry of the lock file whose image has not been pushed yet (as written by pull if the
+// cluster image storage is a docker registry). The source image is resolved by its repo digest, so that it is only pulled if it is not
+// present locally, and is then pushed to the cluster image storage.
+func (u *upRunner) getAppImageInfoFromLockedSourceImage(a *app, lockedImage *LockedImage) error {
+	a.newLogEntry().Debugf("using image %s from lock file", lockedImage.SourceRepoDigest)
+	sourceImageRef, err := dockerRef.ParseNormalizedNamed(lockedImage.SourceRepoDigest)
+	if err != nil {
+		return errors.Wrapf(err, "error while parsing image %#v of lock file", lockedImage.SourceRepoDigest)
+	}
+	localImageIDSet, err := u.getLocalImageIDSet()
+	if err != nil {
+		return err
+	}
+	err = u.getAppImageInfoEnsureSourceImageID(lockedImage.SourceRepoDigest, sourceImageRef, a, localImageIDSet)
+	if err != nil {
+		return err
+	}
+	a.imageInfo.cmd = lockedImage.Cmd
+	a.imageInfo.imageHealthcheck = lockedImage.Healthcheck
+	a.imageInfo.imageUser = lockedImage.User
+	err = u.getAppImageEnsureCorrectPodImage(a, sourceImageRef, lockedImage.SourceRepoDigest)
+	if err != nil {
+		return err
+	}
+	if u.opts.RunAsUser {
+		return u.getAppImageInfoUser(a, lockedImage.User, lockedImage.Image)
+	}
+	return nil
+}
+
+func (u *upRunner) newLock() *Lock {
+	lock := &Lock{
+		EnvironmentID: u.cfg.EnvironmentID,
+		Namespace:     u.cfg.Namespace,
+		Services:      map[string]*LockedImage{},
+	}
+	for a := range u.appsToBeStarted {
+		lock.Services[a.name()] = &LockedImage{
+			Cmd:                a.imageInfo.cmd,
+			Healthcheck:        a.imageInfo.imageHealthcheck,
+			Image:              a.composeService.DockerComposeService.Image,
+			PodImage:           a.imageInfo.podImage,
+			PodImagePullPolicy: a.imageInfo.podImagePullPolicy,
+			SourceImageID:      a.imageInfo.sourceImageID,
+			SourceRepoDigest:   a.imageInfo.sourceRepoDigest,
+			User:               a.imageInfo.imageUser,
+		}
+	}
+	return lock
+}
@@ -0,0 +1,240 @@
+package up
+
+import (
+	"bytes"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/docker/distribution/digestset"
+	dockerRef "github.com/docker/distribution/reference"
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	goDigest "github.com/opencontainers/go-digest"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+func newTestLock() *Lock {
+	return &Lock{
+		EnvironmentID: "env1",
+		Namespace:     "ns1",
+		Services: map[string]*LockedImage{
+			"a": {
+				Cmd: []string{"serve"},
+				Healthcheck: &dockerComposeConfig.Healthcheck{
+					Test: []string{"CMD", "true"},
+				},
+				Image:              "ubuntu:latest",
+				PodImage:           "docker-registry.default.svc:5000/ns1/a@sha256:0000",
+				PodImagePullPolicy: v1.PullAlways,
+				SourceImageID:      "sha256:1111",
+				SourceRepoDigest:   "ubuntu@sha256:2222",
+				User:               "1000:1000",
+			},
+		},
+	}
+}
+
+func newTestLockUpRunner(lock *Lock) (*upRunner, *app) {
+	cfg := newTestConfig()
+	cfg.EnvironmentID = "env1"
+	cfg.Namespace = "ns1"
+	a := &app{
+		composeService: cfg.Services["a"],
+	}
+	a.composeService.DockerComposeService.Image = "ubuntu:latest"
+	u := &upRunner{
+		cfg: cfg,
+		opts: &Options{
+			Lock: lock,
+		},
+	}
+	return u, a
+}
+
+func TestLockWriteReadLock_RoundTrip(t *testing.T) {
+	lock := newTestLock()
+	var buf bytes.Buffer
+	err := lock.Write(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lockRead, err := ReadLock(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(lockRead, lock) {
+		t.Error(lockRead)
+	}
+}
+
+func TestReadLockFile_Success(t *testing.T) {
+	var buf bytes.Buffer
+	_ = newTestLock().Write(&buf)
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/kube-compose.lock": {
+			Content: buf.Bytes(),
+		},
+	})
+	withMockFS(vfs, func() {
+		lock, err := ReadLockFile("/kube-compose.lock")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if lock.Services["a"] == nil || lock.Services["a"].SourceImageID != "sha256:1111" {
+			t.Error(lock)
+		}
+	})
+}
+
+func TestReadLockFile_InvalidJSON(t *testing.T) {
+	vfs := fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/kube-compose.lock": {
+			Content: []byte("{"),
+		},
+	})
+	withMockFS(vfs, func() {
+		_, err := ReadLockFile("/kube-compose.lock")
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestUpRunnerFindLockedImage_Success(t *testing.T) {
+	lock := newTestLock()
+	u, a := newTestLockUpRunner(lock)
+	if u.findLockedImage(a) != lock.Services["a"] {
+		t.Fail()
+	}
+}
+
+func TestUpRunnerFindLockedImage_NoLock(t *testing.T) {
+	u, a := newTestLockUpRunner(nil)
+	if u.findLockedImage(a) != nil {
+		t.Fail()
+	}
+}
+
+func TestUpRunnerFindLockedImage_NotPushed(t *testing.T) {
+	lock := newTestLock()
+	lock.Services["a"].PodImage = ""
+	u, a := newTestLockUpRunner(lock)
+	if u.findLockedImage(a) != lock.Services["a"] {
+		t.Fail()
+	}
+}
+
+func TestUpRunnerFindLockedImage_NotPushedNoRepoDigest(t *testing.T) {
+	lock := newTestLock()
+	lock.Services["a"].PodImage = ""
+	lock.Services["a"].SourceRepoDigest = ""
+	u, a := newTestLockUpRunner(lock)
+	if u.findLockedImage(a) != nil {
+		t.Fail()
+	}
+}
+
+func TestUpRunnerFindLockedImage_OtherEnvironment(t *testing.T) {
+	lock := newTestLock()
+	lock.EnvironmentID = "env2"
+	u, a := newTestLockUpRunner(lock)
+	if u.findLockedImage(a) != nil {
+		t.Fail()
+	}
+}
+
+func TestUpRunnerFindLockedImage_OtherImage(t *testing.T) {
+	lock := newTestLock()
+	u, a := newTestLockUpRunner(lock)
+	a.composeService.DockerComposeService.Image = "ubuntu:18.04"
+	if u.findLockedImage(a) != nil {
+		t.Fail()
+	}
+}
+
+func TestUpRunnerGetAppImageInfoFromLock(t *testing.T) {
+	lock := newTestLock()
+	u, a := newTestLockUpRunner(lock)
+	u.opts.RunAsUser = true
+	err := u.getAppImageInfoFromLock(a, lock.Services["a"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.imageInfo.podImage != lock.Services["a"].PodImage || a.imageInfo.podImagePullPolicy != v1.PullAlways ||
+		a.imageInfo.sourceImageID != "sha256:1111" || a.imageInfo.imageHealthcheck != lock.Services["a"].Healthcheck {
+		t.Error(a.imageInfo)
+	}
+	if !reflect.DeepEqual(a.imageInfo.cmd, []string{"serve"}) {
+		t.Error(a.imageInfo.cmd)
+	}
+	if a.imageInfo.user == nil || a.imageInfo.user.UID == nil || *a.imageInfo.user.UID != 1000 {
+		t.Error(a.imageInfo.user)
+	}
+}
+
+func TestUpRunnerNewLock(t *testing.T) {
+	lock := newTestLock()
+	u, a := newTestLockUpRunner(nil)
+	_ = u.getAppImageInfoFromLock(a, lock.Services["a"])
+	u.appsToBeStarted = map[*app]bool{
+		a: true,
+	}
+	lockNew := u.newLock()
+	if !reflect.DeepEqual(lockNew, lock) {
+		t.Error(lockNew)
+	}
+}
+
+func TestUpRunnerGetAppImageInfoFromLockedSourceImage(t *testing.T) {
+	lock := newTestLock()
+	lockedImage := lock.Services["a"]
+	lockedImage.PodImage = ""
+	lockedImage.PodImagePullPolicy = ""
+	lockedImage.SourceImageID = "sha256:1111111111111111111111111111111111111111111111111111111111111111"
+	lockedImage.SourceRepoDigest = "ubuntu@sha256:2222222222222222222222222222222222222222222222222222222222222222"
+	u, a := newTestLockUpRunner(lock)
+	u.cfg.ClusterImageStorage.DockerRegistry = &config.DockerRegistryClusterImageStorage{}
+	u.skipPush = true
+	u.localImagesCache.once = &sync.Once{}
+	u.localImagesCache.once.Do(func() {})
+	u.localImagesCache.imageIDSet = digestset.NewSet()
+	_ = u.localImagesCache.imageIDSet.Add(goDigest.Digest(lockedImage.SourceImageID))
+	u.localImagesCache.images = []dockerTypes.ImageSummary{
+		{
+			ID:          lockedImage.SourceImageID,
+			RepoDigests: []string{lockedImage.SourceRepoDigest},
+		},
+	}
+	err := u.getAppImageInfoFromLockedSourceImage(a, lockedImage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.imageInfo.sourceImageID != lockedImage.SourceImageID || a.imageInfo.sourceRepoDigest != lockedImage.SourceRepoDigest ||
+		a.imageInfo.podImage != "" || !reflect.DeepEqual(a.imageInfo.cmd, []string{"serve"}) {
+		t.Error(a.imageInfo)
+	}
+}
+
+func TestFindRepoDigest(t *testing.T) {
+	images := []dockerTypes.ImageSummary{
+		{
+			ID:          "sha256:1111",
+			RepoDigests: []string{"nginx@sha256:3333"},
+		},
+		{
+			ID:          "sha256:2222",
+			RepoDigests: []string{"registry.example.com/ubuntu@sha256:4444", "ubuntu@sha256:5555"},
+		},
+	}
+	named, _ := dockerRef.ParseNormalizedNamed("ubuntu:latest")
+	if repoDigest := findRepoDigest(images, "sha256:2222", named); repoDigest != "ubuntu@sha256:5555" {
+		t.Error(repoDigest)
+	}
+	if repoDigest := findRepoDigest(images, "sha256:1111", named); repoDigest != "" {
+		t.Error(repoDigest)
+	}
+}
@@ -0,0 +1,57 @@
+package up
+
+import (
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (u *upRunner) newNamespace() *v1.Namespace {
+	return &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   u.cfg.Namespace,
+			Labels: k8smeta.InitEnvironmentLabels(u.cfg, nil),
+		},
+	}
+}
+
+// createEphemeralNamespace creates the namespace of the environment and its ResourceQuota, if the environment has an ephemeral namespace.
+func (u *upRunner) createEphemeralNamespace() error {
+	if u.cfg.EphemeralNamespace == nil {
+		return nil
+	}
+	namespace := u.newNamespace()
+	_, err := u.k8sClientset.CoreV1().Namespaces().Create(namespace)
+	switch {
+	case k8sError.IsAlreadyExists(err):
+		log.Debugf("namespace %s already exists", namespace.ObjectMeta.Name)
+	case err != nil:
+		return err
+	default:
+		log.Infof("created namespace %s", namespace.ObjectMeta.Name)
+	}
+	if u.cfg.EphemeralNamespace.ResourceQuota == nil {
+		return nil
+	}
+	resourceQuota := &v1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   k8smeta.ResourceQuotaName,
+			Labels: k8smeta.InitEnvironmentLabels(u.cfg, nil),
+		},
+		Spec: v1.ResourceQuotaSpec{
+			Hard: u.cfg.EphemeralNamespace.ResourceQuota,
+		},
+	}
+	_, err = u.k8sClientset.CoreV1().ResourceQuotas(u.cfg.Namespace).Create(resourceQuota)
+	switch {
+	case k8sError.IsAlreadyExists(err):
+		log.Debugf("resource quota %s already exists", resourceQuota.ObjectMeta.Name)
+	case err != nil:
+		return err
+	default:
+		log.Infof("created resource quota %s", resourceQuota.ObjectMeta.Name)
+	}
+	return nil
+}
@@ -0,0 +1,238 @@
+package up
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8swatch "k8s.io/apimachinery/pkg/watch"
+)
+
+// OneOffOptions are the options of RunOneOff.
+type OneOffOptions struct {
+	// If not empty, overrides the command of the docker compose service.
+	Command []string
+	// If not nil, overrides the entrypoint of the docker compose service.
+	Entrypoint []string
+	// Environment variables that are added to the environment of the docker compose service, overriding existing ones.
+	Environment map[string]string
+	// If true, the pod is deleted after its container has terminated.
+	Remove  bool
+	Service *config.Service
+	Stdout  io.Writer
+}
+
+type oneOff struct {
+	opts *OneOffOptions
+	// The one-off pod as returned by the Kubernetes API, or nil if it has not been created yet.
+	pod *v1.Pod
+}
+
+// newOneOffApp returns a copy of the app whose docker compose service has the overrides of opts applied.
+func newOneOffApp(a *app, opts *OneOffOptions) *app {
+	dcService := *a.composeService.DockerComposeService
+	if opts.Entrypoint != nil {
+		dcService.Entrypoint = opts.Entrypoint
+	}
+	if len(opts.Command) > 0 {
+		dcService.Command = opts.Command
+	}
+	if len(opts.Environment) > 0 {
+		environment := map[string]string{}
+		for key, value := range dcService.Environment {
+			environment[key] = value
+		}
+		for key, value := range opts.Environment {
+			environment[key] = value
+		}
+		dcService.Environment = environment
+	}
+	composeService := *a.composeService
+	composeService.DockerComposeService = &dcService
+	oneOffApp := *a
+	oneOffApp.composeService = &composeService
+	return &oneOffApp
+}
+
+// newOneOffPod returns the one-off pod of the app. The pod is named after the app with a unique suffix generated by Kubernetes, and is not
+// selected by the app's Kubernetes Service or controller.
+func (u *upRunner) newOneOffPod(a *app) (*v1.Pod, error) {
+	pod, err := u.newPod(newOneOffApp(a, u.oneOff.opts))
+	if err != nil {
+		return nil, err
+	}
+	pod.ObjectMeta.GenerateName = k8smeta.GetK8sName(a.composeService, u.cfg) + "-run-"
+	pod.ObjectMeta.Name = ""
+	delete(pod.ObjectMeta.Labels, "app")
+	pod.ObjectMeta.Annotations[k8smeta.AnnotationOneOff] = "true"
+	pod.Spec.RestartPolicy = v1.RestartPolicyNever
+	return pod, nil
+}
+
+func (u *upRunner) createOneOffPod() (*v1.Pod, error) {
+	a := u.apps[u.oneOff.opts.Service.Name()]
+	// The image information is stored in the app, so get it before the app is copied.
+	err := u.getAppImageInfoOnce(a)
+	if err != nil {
+		return nil, err
+	}
+	if len(a.volumes) > 0 {
+		err = u.getAppVolumeInitImageOnce(a)
+		if err != nil {
+			return nil, err
+		}
+	}
+	pod, err := u.newOneOffPod(a)
+	if err != nil {
+		return nil, err
+	}
+	podServer, err := u.k8sPodClient.Create(pod)
+	if err != nil {
+		return nil, err
+	}
+	a.newLogEntry().Infof("created pod %s", podServer.ObjectMeta.Name)
+	u.oneOff.pod = podServer
+	return podServer, nil
+}
+
+func getContainerState(pod *v1.Pod, containerName string) *v1.ContainerState {
+	for i := 0; i < len(pod.Status.ContainerStatuses); i++ {
+		if pod.Status.ContainerStatuses[i].Name == containerName {
+			return &pod.Status.ContainerStatuses[i].State
+		}
+	}
+	return nil
+}
+
+// isOneOffContainerStarted returns true if the container of the one-off pod is running or has terminated, and an error if the container
+// will never start.
+func isOneOffContainerStarted(pod *v1.Pod, containerName string) (bool, error) {
+	state := getContainerState(pod, containerName)
+	if state != nil {
+		if state.Running != nil || state.Terminated != nil {
+			return true, nil
+		}
+		if w := state.Waiting; w != nil && (w.Reason == "ErrImagePull" || w.Reason == "ImagePullBackOff") {
+			return false, fmt.Errorf("container %s of pod %s could not pull image: %s", containerName, pod.ObjectMeta.Name, w.Message)
+		}
+	}
+	if pod.Status.Phase == v1.PodFailed {
+		return false, fmt.Errorf("pod %s failed before container %s started: %s", pod.ObjectMeta.Name, containerName, pod.Status.Message)
+	}
+	return false, nil
+}
+
+func isOneOffContainerTerminated(pod *v1.Pod, containerName string) (bool, error) {
+	state := getContainerState(pod, containerName)
+	return state != nil && state.Terminated != nil, nil
+}
+
+// waitForOneOffPod waits until the condition is true for the one-off pod, and returns the pod.
+func (u *upRunner) waitForOneOffPod(containerName string, condition func(*v1.Pod, string) (bool, error)) (*v1.Pod, error) {
+	listOptions := metav1.ListOptions{
+		FieldSelector: "metadata.name=" + u.oneOff.pod.ObjectMeta.Name,
+	}
+	podList, err := u.k8sPodClient.List(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	if len(podList.Items) == 0 {
+		return nil, k8smeta.ErrorResourcesModifiedExternally()
+	}
+	pod := &podList.Items[0]
+	if done, err := condition(pod, containerName); done || err != nil {
+		return pod, err
+	}
+	listOptions.ResourceVersion = podList.ResourceVersion
+	listOptions.Watch = true
+	watch, err := u.k8sPodClient.Watch(listOptions)
+	if err != nil {
+		return nil, err
+	}
+	defer watch.Stop()
+	eventChannel := watch.ResultChan()
+	for {
+		event, ok := <-eventChannel
+		if !ok {
+			return nil, fmt.Errorf("channel unexpectedly closed")
+		}
+		switch event.Type {
+		case k8swatch.Added, k8swatch.Modified:
+			pod = event.Object.(*v1.Pod)
+			if done, err := condition(pod, containerName); done || err != nil {
+				return pod, err
+			}
+		case k8swatch.Deleted:
+			return nil, k8smeta.ErrorResourcesModifiedExternally()
+		default:
+			return nil, fmt.Errorf("got unexpected error event from channel: %+v", event.Object)
+		}
+	}
+}
+
+// attachOneOffPod copies the output of the container of the one-off pod to stdout, and returns the exit code of the container.
+func (u *upRunner) attachOneOffPod() (int, error) {
+	containerName := u.oneOff.opts.Service.NameEscaped
+	_, err := u.waitForOneOffPod(containerName, isOneOffContainerStarted)
+	if err != nil {
+		return 0, err
+	}
+	bodyReader, err := u.k8sPodClient.GetLogs(u.oneOff.pod.ObjectMeta.Name, &v1.PodLogOptions{
+		Container: containerName,
+		Follow:    true,
+	}).Stream()
+	if err != nil {
+		return 0, err
+	}
+	_, err = io.Copy(u.oneOff.opts.Stdout, bodyReader)
+	util.CloseAndLogError(bodyReader)
+	if err != nil {
+		return 0, err
+	}
+	pod, err := u.waitForOneOffPod(containerName, isOneOffContainerTerminated)
+	if err != nil {
+		return 0, err
+	}
+	return int(getContainerState(pod, containerName).Terminated.ExitCode), nil
+}
+
+func (u *upRunner) deleteOneOffPod() {
+	name := u.oneOff.pod.ObjectMeta.Name
+	err := u.k8sPodClient.Delete(name, &metav1.DeleteOptions{})
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	log.Debugf("deleted pod %s", name)
+}
+
+// RunOneOff runs a one-off pod of a docker compose service, similar to docker-compose run. The dependencies of the docker compose service
+// are started first, as by Run. The output of the pod's container is copied to stdout, and the exit code of the container is returned.
+func RunOneOff(cfg *config.Config, opts *Options, oneOffOpts *OneOffOptions) (int, error) {
+	// The logs of the one-off pod are attached separately.
+	upOpts := *opts
+	upOpts.Detach = true
+	u := &upRunner{
+		cfg:  cfg,
+		opts: &upOpts,
+		oneOff: &oneOff{
+			opts: oneOffOpts,
+		},
+	}
+	u.hostAliases.once = &sync.Once{}
+	u.localImagesCache.once = &sync.Once{}
+	err := u.run()
+	if u.oneOff.pod != nil && oneOffOpts.Remove {
+		defer u.deleteOneOffPod()
+	}
+	if err != nil {
+		return 0, err
+	}
+	return u.attachOneOffPod()
+}
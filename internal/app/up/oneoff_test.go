@@ -0,0 +1,162 @@
+package up
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	v1 "k8s.io/api/core/v1"
+)
+
+// newDoneOnce returns a sync.Once that has already been done, so that results can be injected into upRunner and app.
+func newDoneOnce() *sync.Once {
+	once := &sync.Once{}
+	once.Do(func() {})
+	return once
+}
+
+func TestNewOneOffApp(t *testing.T) {
+	a := newTestApp("a")
+	a.composeService.DockerComposeService.Entrypoint = []string{"/entrypoint.sh"}
+	a.composeService.DockerComposeService.Command = []string{"serve"}
+	a.composeService.DockerComposeService.Environment = map[string]string{
+		"K1": "V1",
+		"K2": "V2",
+	}
+	oneOffApp := newOneOffApp(a, &OneOffOptions{
+		Command: []string{"migrate"},
+		Environment: map[string]string{
+			"K2": "V3",
+		},
+	})
+	dcService := oneOffApp.composeService.DockerComposeService
+	if !reflect.DeepEqual(dcService.Entrypoint, []string{"/entrypoint.sh"}) {
+		t.Error(dcService.Entrypoint)
+	}
+	if !reflect.DeepEqual(dcService.Command, []string{"migrate"}) {
+		t.Error(dcService.Command)
+	}
+	if !reflect.DeepEqual(dcService.Environment, map[string]string{"K1": "V1", "K2": "V3"}) {
+		t.Error(dcService.Environment)
+	}
+	// The original app must not be modified.
+	if !reflect.DeepEqual(a.composeService.DockerComposeService.Command, []string{"serve"}) ||
+		a.composeService.DockerComposeService.Environment["K2"] != "V2" {
+		t.Fail()
+	}
+	if oneOffApp.name() != a.name() {
+		t.Fail()
+	}
+}
+
+func TestNewOneOffApp_EmptyEntrypoint(t *testing.T) {
+	a := newTestApp("a")
+	oneOffApp := newOneOffApp(a, &OneOffOptions{
+		Entrypoint: []string{},
+	})
+	entrypoint := oneOffApp.composeService.DockerComposeService.Entrypoint
+	if entrypoint == nil || len(entrypoint) != 0 {
+		t.Error(entrypoint)
+	}
+}
+
+func TestUpRunnerNewOneOffPod(t *testing.T) {
+	cfg := newTestConfig()
+	cfg.EnvironmentID = "env1"
+	cfg.EnvironmentLabel = "env"
+	cfg.ServiceDiscovery = config.ServiceDiscoveryDNS
+	a := &app{
+		composeService: cfg.Services["b"],
+	}
+	a.imageInfo.once = newDoneOnce()
+	a.imageInfo.podImage = "ubuntu:latest"
+	u := &upRunner{
+		cfg:  cfg,
+		opts: &Options{},
+		oneOff: &oneOff{
+			opts: &OneOffOptions{
+				Command: []string{"echo", "hello"},
+				Service: a.composeService,
+			},
+		},
+	}
+	u.hostAliases.once = newDoneOnce()
+	pod, err := u.newOneOffPod(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod.ObjectMeta.Name != "" || pod.ObjectMeta.GenerateName != "b-env1-run-" {
+		t.Error(pod.ObjectMeta.Name, pod.ObjectMeta.GenerateName)
+	}
+	if _, ok := pod.ObjectMeta.Labels["app"]; ok {
+		t.Error(pod.ObjectMeta.Labels)
+	}
+	if pod.ObjectMeta.Labels["env"] != "env1" || !k8smeta.IsOneOff(&pod.ObjectMeta) {
+		t.Error(pod.ObjectMeta)
+	}
+	if pod.Spec.RestartPolicy != v1.RestartPolicyNever {
+		t.Error(pod.Spec.RestartPolicy)
+	}
+	if !reflect.DeepEqual(pod.Spec.Containers[0].Args, []string{"echo", "hello"}) {
+		t.Error(pod.Spec.Containers[0].Args)
+	}
+}
+
+func newTestOneOffPod(state v1.ContainerState, phase v1.PodPhase) *v1.Pod {
+	return &v1.Pod{
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					Name:  "a",
+					State: state,
+				},
+			},
+			Phase: phase,
+		},
+	}
+}
+
+func TestIsOneOffContainerStarted_Running(t *testing.T) {
+	pod := newTestOneOffPod(v1.ContainerState{Running: &v1.ContainerStateRunning{}}, v1.PodRunning)
+	started, err := isOneOffContainerStarted(pod, "a")
+	if !started || err != nil {
+		t.Fail()
+	}
+}
+
+func TestIsOneOffContainerStarted_Waiting(t *testing.T) {
+	pod := newTestOneOffPod(v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ContainerCreating"}}, v1.PodPending)
+	started, err := isOneOffContainerStarted(pod, "a")
+	if started || err != nil {
+		t.Fail()
+	}
+}
+
+func TestIsOneOffContainerStarted_ErrImagePull(t *testing.T) {
+	pod := newTestOneOffPod(v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "ErrImagePull"}}, v1.PodPending)
+	_, err := isOneOffContainerStarted(pod, "a")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestIsOneOffContainerStarted_PodFailed(t *testing.T) {
+	pod := newTestOneOffPod(v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "PodInitializing"}}, v1.PodFailed)
+	_, err := isOneOffContainerStarted(pod, "a")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestIsOneOffContainerTerminated(t *testing.T) {
+	pod := newTestOneOffPod(v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 3}}, v1.PodFailed)
+	terminated, err := isOneOffContainerTerminated(pod, "a")
+	if !terminated || err != nil {
+		t.Fail()
+	}
+	if getContainerState(pod, "b") != nil {
+		t.Fail()
+	}
+}
@@ -7,9 +7,19 @@ import (
 )
 
 type Options struct {
-	Context  context.Context
-	Detach   bool
-	Reporter *reporter.Reporter
+	Context context.Context
+	// True to inspect images through the registry API instead of pulling them into the local docker daemon, if images are not pushed to
+	// the cluster image storage.
+	Daemonless bool
+	Detach     bool
+	// True to create a Secret with the credentials of the docker registries that pods pull images from, and
+	// reference it from the imagePullSecrets of every pod.
+	ImagePullSecret bool
+	// If not nil, the images of docker compose services are taken from the lock instead of being pulled and pushed (see Pull and Push).
+	Lock *Lock
+	// True to delete the resources of docker compose services that are not in the docker compose file before starting.
+	RemoveOrphans bool
+	Reporter      *reporter.Reporter
 	// True to set runAsUser/runAsGroup for each pod based on the user of the pod's image and the "user" key of the pod's docker-compose
 	// service.
 	RunAsUser bool
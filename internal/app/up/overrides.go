@@ -0,0 +1,70 @@
+package up
+
+import (
+	"encoding/json"
+	"fmt"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/pkg/errors"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// applyPodOverrides applies the Kubernetes specific settings of a docker compose service (see config.PodOverrides) to a pod, except for
+// patches (see applyPodPatches).
+func applyPodOverrides(pod *v1.Pod, o *config.PodOverrides) {
+	if len(o.Annotations) > 0 {
+		if pod.ObjectMeta.Annotations == nil {
+			pod.ObjectMeta.Annotations = map[string]string{}
+		}
+		for key, value := range o.Annotations {
+			pod.ObjectMeta.Annotations[key] = value
+		}
+	}
+	if o.ImagePullPolicy != "" {
+		pod.Spec.Containers[0].ImagePullPolicy = o.ImagePullPolicy
+	}
+	pod.Spec.NodeSelector = o.NodeSelector
+	pod.Spec.PriorityClassName = o.PriorityClassName
+	pod.Spec.ServiceAccountName = o.ServiceAccountName
+	pod.Spec.Tolerations = o.Tolerations
+}
+
+// applyPodPatches applies the patches of a docker compose service (in order) to a pod, returning the patched pod.
+func applyPodPatches(pod *v1.Pod, patches []config.PodPatch) (*v1.Pod, error) {
+	if len(patches) == 0 {
+		return pod, nil
+	}
+	data, err := json.Marshal(pod)
+	if err != nil {
+		return nil, err
+	}
+	for _, patch := range patches {
+		data, err = applyPodPatch(data, &patch)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error while patching pod %s", pod.ObjectMeta.Name)
+		}
+	}
+	podPatched := &v1.Pod{}
+	err = json.Unmarshal(data, podPatched)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error while patching pod %s", pod.ObjectMeta.Name)
+	}
+	return podPatched, nil
+}
+
+func applyPodPatch(data []byte, patch *config.PodPatch) ([]byte, error) {
+	switch patch.Type {
+	case types.StrategicMergePatchType:
+		return strategicpatch.StrategicMergePatch(data, patch.Data, v1.Pod{})
+	case types.JSONPatchType:
+		jsonPatch, err := jsonpatch.DecodePatch(patch.Data)
+		if err != nil {
+			return nil, err
+		}
+		return jsonPatch.Apply(data)
+	}
+	return nil, fmt.Errorf("unsupported patch type %s", patch.Type)
+}
@@ -0,0 +1,73 @@
+package up
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newTestPod() *v1.Pod {
+	return &v1.Pod{
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{
+				{
+					Name:  "a",
+					Image: "ubuntu:latest",
+				},
+			},
+		},
+	}
+}
+
+func TestApplyPodOverrides(t *testing.T) {
+	pod := newTestPod()
+	applyPodOverrides(pod, &config.PodOverrides{
+		Annotations: map[string]string{
+			"key": "value",
+		},
+		ImagePullPolicy:    v1.PullIfNotPresent,
+		PriorityClassName:  "high",
+		ServiceAccountName: "sa",
+	})
+	if pod.ObjectMeta.Annotations["key"] != "value" || pod.Spec.Containers[0].ImagePullPolicy != v1.PullIfNotPresent {
+		t.Fail()
+	}
+	if pod.Spec.PriorityClassName != "high" || pod.Spec.ServiceAccountName != "sa" {
+		t.Fail()
+	}
+}
+
+func TestApplyPodPatches_Success(t *testing.T) {
+	pod, err := applyPodPatches(newTestPod(), []config.PodPatch{
+		{
+			Data: []byte(`{"spec":{"containers":[{"name":"a","workingDir":"/app"}]}}`),
+			Type: types.StrategicMergePatchType,
+		},
+		{
+			Data: []byte(`[{"op":"add","path":"/spec/hostname","value":"myhost"}]`),
+			Type: types.JSONPatchType,
+		},
+	})
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if pod.Spec.Hostname != "myhost" || len(pod.Spec.Containers) != 1 || pod.Spec.Containers[0].WorkingDir != "/app" ||
+		pod.Spec.Containers[0].Image != "ubuntu:latest" {
+		t.Fail()
+	}
+}
+
+func TestApplyPodPatches_Error(t *testing.T) {
+	_, err := applyPodPatches(newTestPod(), []config.PodPatch{
+		{
+			Data: []byte(`[{"op":"test","path":"/spec/containers/0/name","value":"b"}]`),
+			Type: types.JSONPatchType,
+		},
+	})
+	if err == nil {
+		t.Fail()
+	}
+}
@@ -0,0 +1,76 @@
+package up
+
+import (
+	"fmt"
+	"strings"
+
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+type servicePortKey struct {
+	port     int32
+	protocol string
+}
+
+type servicePortsBuilder struct {
+	published    map[servicePortKey]bool
+	seen         map[servicePortKey]bool
+	servicePorts []v1.ServicePort
+	serviceType  v1.ServiceType
+}
+
+func (b *servicePortsBuilder) add(protocol string, port, targetPort int32, name string) {
+	key := servicePortKey{
+		port:     port,
+		protocol: protocol,
+	}
+	if b.seen[key] {
+		return
+	}
+	b.seen[key] = true
+	servicePort := v1.ServicePort{
+		Name:       name,
+		Port:       port,
+		Protocol:   v1.Protocol(strings.ToUpper(protocol)),
+		TargetPort: intstr.FromInt(int(targetPort)),
+	}
+	// Honor the published port by requesting it as the node port, if it is in the default node port range. Otherwise a node port is
+	// allocated by Kubernetes.
+	if b.serviceType == v1.ServiceTypeNodePort && b.published[key] && isNodePortInRange(port) {
+		servicePort.NodePort = port
+	}
+	b.servicePorts = append(b.servicePorts, servicePort)
+}
+
+// newServicePorts returns the ports of the Kubernetes Service of a docker compose service. Each internal port is a service port, so that
+// other containers can connect to the port the container listens on. Each published port is a service port as well, targeting the internal
+// port, so that clients that use the published port (e.g. from the host) can connect too. Port ranges are expanded into individual service
+// ports. If multiple port bindings map to the same service port then the first one takes precedence.
+func newServicePorts(ports []dockerComposeConfig.PortBinding, serviceType v1.ServiceType) []v1.ServicePort {
+	b := &servicePortsBuilder{
+		published:   map[servicePortKey]bool{},
+		seen:        map[servicePortKey]bool{},
+		serviceType: serviceType,
+	}
+	for i := range ports {
+		if isPublished(&ports[i]) {
+			for external := ports[i].ExternalMin; external <= ports[i].ExternalMax; external++ {
+				b.published[servicePortKey{port: external, protocol: ports[i].Protocol}] = true
+			}
+		}
+	}
+	for i := range ports {
+		b.add(ports[i].Protocol, ports[i].Internal, ports[i].Internal, fmt.Sprintf("%s%d", ports[i].Protocol, ports[i].Internal))
+	}
+	for i := range ports {
+		if !isPublished(&ports[i]) {
+			continue
+		}
+		for external := ports[i].ExternalMin; external <= ports[i].ExternalMax; external++ {
+			b.add(ports[i].Protocol, external, ports[i].Internal, fmt.Sprintf("%s%d-%d", ports[i].Protocol, external, ports[i].Internal))
+		}
+	}
+	return b.servicePorts
+}
@@ -0,0 +1,82 @@
+package up
+
+import (
+	"reflect"
+	"testing"
+
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestNewServicePorts_PublishedPort(t *testing.T) {
+	ports := []dockerComposeConfig.PortBinding{
+		{Internal: 8234, ExternalMin: 8236, ExternalMax: 8236, Protocol: "tcp"},
+		{Internal: 8080, ExternalMin: -1, ExternalMax: -1, Protocol: "tcp"},
+	}
+	servicePorts := newServicePorts(ports, v1.ServiceTypeClusterIP)
+	expected := []v1.ServicePort{
+		{Name: "tcp8234", Port: 8234, Protocol: v1.ProtocolTCP, TargetPort: intstr.FromInt(8234)},
+		{Name: "tcp8080", Port: 8080, Protocol: v1.ProtocolTCP, TargetPort: intstr.FromInt(8080)},
+		{Name: "tcp8236-8234", Port: 8236, Protocol: v1.ProtocolTCP, TargetPort: intstr.FromInt(8234)},
+	}
+	if !reflect.DeepEqual(servicePorts, expected) {
+		t.Error(servicePorts)
+	}
+}
+
+func TestNewServicePorts_ExpandsRange(t *testing.T) {
+	ports := []dockerComposeConfig.PortBinding{
+		{Internal: 80, ExternalMin: 8000, ExternalMax: 8002, Protocol: "udp"},
+	}
+	servicePorts := newServicePorts(ports, v1.ServiceTypeClusterIP)
+	if len(servicePorts) != 4 {
+		t.Fatal(servicePorts)
+	}
+	for i, external := range []int32{8000, 8001, 8002} {
+		servicePort := servicePorts[i+1]
+		if servicePort.Port != external || servicePort.TargetPort != intstr.FromInt(80) || servicePort.Protocol != v1.ProtocolUDP {
+			t.Error(servicePort)
+		}
+	}
+}
+
+func TestNewServicePorts_Deduplicates(t *testing.T) {
+	ports := []dockerComposeConfig.PortBinding{
+		{Internal: 80, ExternalMin: 80, ExternalMax: 80, Protocol: "tcp"},
+		{Internal: 80, ExternalMin: 8080, ExternalMax: 8080, Protocol: "tcp"},
+		{Internal: 80, ExternalMin: -1, ExternalMax: -1, Protocol: "udp"},
+	}
+	servicePorts := newServicePorts(ports, v1.ServiceTypeClusterIP)
+	expected := []v1.ServicePort{
+		{Name: "tcp80", Port: 80, Protocol: v1.ProtocolTCP, TargetPort: intstr.FromInt(80)},
+		{Name: "udp80", Port: 80, Protocol: v1.ProtocolUDP, TargetPort: intstr.FromInt(80)},
+		{Name: "tcp8080-80", Port: 8080, Protocol: v1.ProtocolTCP, TargetPort: intstr.FromInt(80)},
+	}
+	if !reflect.DeepEqual(servicePorts, expected) {
+		t.Error(servicePorts)
+	}
+}
+
+func TestNewServicePorts_NodePort(t *testing.T) {
+	ports := []dockerComposeConfig.PortBinding{
+		{Internal: 80, ExternalMin: 30080, ExternalMax: 30080, Protocol: "tcp"},
+		{Internal: 81, ExternalMin: 8081, ExternalMax: 8081, Protocol: "tcp"},
+		{Internal: 30082, ExternalMin: 30082, ExternalMax: 30082, Protocol: "tcp"},
+	}
+	servicePorts := newServicePorts(ports, v1.ServiceTypeNodePort)
+	nodePorts := map[int32]int32{}
+	for _, servicePort := range servicePorts {
+		nodePorts[servicePort.Port] = servicePort.NodePort
+	}
+	expected := map[int32]int32{
+		80:    0,
+		81:    0,
+		30082: 30082,
+		30080: 30080,
+		8081:  0,
+	}
+	if !reflect.DeepEqual(nodePorts, expected) {
+		t.Error(nodePorts)
+	}
+}
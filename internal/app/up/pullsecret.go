@@ -0,0 +1,153 @@
+package up
+
+import (
+	"encoding/json"
+
+	log "github.com/Sirupsen/logrus"
+	dockerRef "github.com/docker/distribution/reference"
+	dockerTypes "github.com/docker/docker/api/types"
+	dockerClient "github.com/docker/docker/client"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/app/registry"
+	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// initDockerClient creates the docker client and loads the docker config file.
+func (u *upRunner) initDockerClient() error {
+	dc, err := dockerClient.NewEnvClient()
+	if err != nil {
+		return err
+	}
+	u.dockerClient = dc
+	u.dockerConfigFile, err = docker.LoadDefaultConfigFile()
+	return err
+}
+
+// getDockerConfigAuthConfig returns the credentials of a registry host from the docker config file.
+func (u *upRunner) getDockerConfigAuthConfig(host string) (*dockerTypes.AuthConfig, error) {
+	if u.dockerConfigFile == nil {
+		return &dockerTypes.AuthConfig{}, nil
+	}
+	return u.dockerConfigFile.GetAuthConfig(host)
+}
+
+// getRegistryAuth returns the registry auth of the docker daemon API for a registry host, with the credentials of the docker config file.
+func (u *upRunner) getRegistryAuth(host string) (string, error) {
+	authConfig, err := u.getDockerConfigAuthConfig(host)
+	if err != nil {
+		return "", err
+	}
+	return docker.EncodeAuthConfig(authConfig), nil
+}
+
+// getImagePullSecretAuthConfigs returns the credentials of the docker registries that pods pull images from, by the key of the registry in
+// a docker config file. The registries are determined from the docker compose file, so that the Secret can be created before any image is
+// pulled.
+func (u *upRunner) getImagePullSecretAuthConfigs() (map[string]*dockerTypes.AuthConfig, error) {
+	authConfigs := map[string]*dockerTypes.AuthConfig{}
+	switch {
+	case u.cfg.ClusterImageStorage.Docker != nil:
+		// Pods use images of the docker daemon of the cluster.
+	case u.cfg.ClusterImageStorage.DockerRegistry != nil:
+		// The bearer token of the kube config is only valid outside of the cluster, so only credentials of the docker config file are
+		// used.
+		if u.cfg.ClusterImageStorage.DockerRegistry.Auth != config.RegistryAuthDockerConfig {
+			break
+		}
+		authConfig, err := registry.GetAuthConfig(u.cfg)
+		if err != nil {
+			return nil, err
+		}
+		pullHost := u.cfg.ClusterImageStorage.DockerRegistry.PullHost
+		if pullHost == "" {
+			pullHost = registry.DefaultPullHost
+		}
+		authConfigs[pullHost] = authConfig
+	default:
+		for a := range u.appsToBeStarted {
+			named, err := dockerRef.ParseNormalizedNamed(a.composeService.DockerComposeService.Image)
+			if err != nil {
+				// Images that are not named are resolved locally and can therefore not be pulled by the cluster.
+				continue
+			}
+			host := dockerRef.Domain(named)
+			key := host
+			if host == docker.DefaultDomain {
+				key = docker.DockerHubConfigKey
+			}
+			if _, ok := authConfigs[key]; ok {
+				continue
+			}
+			authConfig, err := u.getDockerConfigAuthConfig(host)
+			if err != nil {
+				return nil, err
+			}
+			authConfigs[key] = authConfig
+		}
+	}
+	for key, authConfig := range authConfigs {
+		if authConfig.Username == "" && authConfig.Password == "" {
+			if authConfig.IdentityToken != "" {
+				log.Warnf("the credentials of docker registry %s are an identity token, which cannot be used by image pull secrets", key)
+			}
+			delete(authConfigs, key)
+		}
+	}
+	return authConfigs, nil
+}
+
+// newImagePullSecret returns the Secret with the credentials of the docker registries that pods pull images from, or nil if there are no
+// such credentials.
+func (u *upRunner) newImagePullSecret() (*v1.Secret, error) {
+	authConfigs, err := u.getImagePullSecretAuthConfigs()
+	if err != nil || len(authConfigs) == 0 {
+		return nil, err
+	}
+	data, err := json.Marshal(docker.NewConfigFile(authConfigs))
+	if err != nil {
+		return nil, err
+	}
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   k8smeta.GetImagePullSecretName(u.cfg),
+			Labels: k8smeta.InitEnvironmentLabels(u.cfg, nil),
+		},
+		Type: v1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{
+			v1.DockerConfigJsonKey: data,
+		},
+	}, nil
+}
+
+// createImagePullSecret creates or updates the Secret returned by newImagePullSecret, so that pods can pull images from private docker
+// registries. The Secret is updated if it already exists because credentials may have expired since it was created.
+func (u *upRunner) createImagePullSecret() error {
+	secret, err := u.newImagePullSecret()
+	if err != nil {
+		return err
+	}
+	if secret == nil {
+		log.Debug("not creating an image pull secret, because there are no credentials of the docker registries that pods pull from")
+		return nil
+	}
+	secretClient := u.k8sClientset.CoreV1().Secrets(u.cfg.Namespace)
+	_, err = secretClient.Create(secret)
+	switch {
+	case k8sError.IsAlreadyExists(err):
+		_, err = secretClient.Update(secret)
+		if err != nil {
+			return err
+		}
+		log.Debugf("updated secret %s", secret.ObjectMeta.Name)
+	case err != nil:
+		return err
+	default:
+		log.Infof("created secret %s", secret.ObjectMeta.Name)
+	}
+	u.imagePullSecretName = secret.ObjectMeta.Name
+	return nil
+}
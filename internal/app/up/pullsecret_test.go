@@ -0,0 +1,107 @@
+package up
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/pkg/docker"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+)
+
+func newTestImagePullSecretRunner(images ...string) *upRunner {
+	cfg := &config.Config{
+		EnvironmentID:    "env1",
+		EnvironmentLabel: "env",
+	}
+	u := &upRunner{
+		appsToBeStarted: map[*app]bool{},
+		cfg:             cfg,
+		dockerConfigFile: &docker.ConfigFile{
+			Auths: map[string]*docker.ConfigAuth{
+				docker.DockerHubConfigKey: {
+					Username: "user1",
+					Password: "password1",
+				},
+				"registry.example.com": {
+					Username: "user2",
+					Password: "password2",
+				},
+			},
+		},
+	}
+	for i, image := range images {
+		composeService := cfg.AddService(&dockerComposeConfig.Service{
+			Image: image,
+			Name:  string(rune('a' + i)),
+		})
+		u.appsToBeStarted[&app{composeService: composeService}] = true
+	}
+	return u
+}
+
+func TestNewImagePullSecret_Success(t *testing.T) {
+	u := newTestImagePullSecretRunner("ubuntu:latest", "registry.example.com/a:latest", "registry.example.com/b:latest",
+		"other.example.com/c:latest")
+	secret, err := u.newImagePullSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if secret.ObjectMeta.Name != "kube-compose-image-pull-env1" || secret.Type != v1.SecretTypeDockerConfigJson ||
+		secret.ObjectMeta.Labels["env"] != "env1" {
+		t.Error(secret)
+	}
+	var configFile docker.ConfigFile
+	err = json.Unmarshal(secret.Data[v1.DockerConfigJsonKey], &configFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configFile.Auths) != 2 {
+		t.Error(configFile.Auths)
+	}
+	a := configFile.Auths[docker.DockerHubConfigKey]
+	if a == nil || a.Username != "user1" || a.Password != "password1" || a.Auth != "dXNlcjE6cGFzc3dvcmQx" {
+		t.Error(a)
+	}
+	a = configFile.Auths["registry.example.com"]
+	if a == nil || a.Username != "user2" || a.Password != "password2" {
+		t.Error(a)
+	}
+}
+
+func TestNewImagePullSecret_NoCredentials(t *testing.T) {
+	u := newTestImagePullSecretRunner("other.example.com/c:latest")
+	secret, err := u.newImagePullSecret()
+	if err != nil || secret != nil {
+		t.Error(secret, err)
+	}
+}
+
+func TestNewImagePullSecret_ClusterImageStorageDocker(t *testing.T) {
+	u := newTestImagePullSecretRunner("ubuntu:latest")
+	u.cfg.ClusterImageStorage.Docker = &struct{}{}
+	secret, err := u.newImagePullSecret()
+	if err != nil || secret != nil {
+		t.Error(secret, err)
+	}
+}
+
+func TestNewImagePullSecret_ClusterImageStorageDockerRegistryKubeBearerToken(t *testing.T) {
+	u := newTestImagePullSecretRunner("ubuntu:latest")
+	u.cfg.ClusterImageStorage.DockerRegistry = &config.DockerRegistryClusterImageStorage{
+		Host: "registry.example.com",
+	}
+	secret, err := u.newImagePullSecret()
+	if err != nil || secret != nil {
+		t.Error(secret, err)
+	}
+}
+
+func TestGetRegistryAuth_NoDockerConfigFile(t *testing.T) {
+	u := &upRunner{}
+	registryAuth, err := u.getRegistryAuth("registry.example.com")
+	if err != nil || registryAuth != docker.EncodeRegistryAuth("", "") {
+		t.Error(registryAuth, err)
+	}
+}
@@ -13,7 +13,11 @@ import (
 	dockerTypes "github.com/docker/docker/api/types"
 	dockerClient "github.com/docker/docker/client"
 	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/down"
 	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/app/logs"
+	"github.com/kube-compose/kube-compose/internal/app/podstatus"
+	"github.com/kube-compose/kube-compose/internal/app/registry"
 	"github.com/kube-compose/kube-compose/internal/pkg/docker"
 	"github.com/kube-compose/kube-compose/internal/pkg/progress/reporter"
 	"github.com/kube-compose/kube-compose/internal/pkg/util"
@@ -23,21 +27,14 @@ import (
 	v1 "k8s.io/api/core/v1"
 	k8sError "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/intstr"
 	k8swatch "k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/kubernetes"
+	clientAppsV1 "k8s.io/client-go/kubernetes/typed/apps/v1"
+	clientBatchV1 "k8s.io/client-go/kubernetes/typed/batch/v1"
 	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	clientExtensionsV1beta1 "k8s.io/client-go/kubernetes/typed/extensions/v1beta1"
 )
 
-// This doesn't deserve the name palette.
-var appColorPalette = []int{
-	37, // gray
-	36, // blue
-	35, // magenta
-	33, // yellow
-	32, // green
-}
-
 type appImageInfo struct {
 	err                error
 	imageHealthcheck   *dockerComposeConfig.Healthcheck
@@ -45,8 +42,12 @@ type appImageInfo struct {
 	podImage           string
 	podImagePullPolicy v1.PullPolicy
 	sourceImageID      string
-	cmd                []string
-	user               *docker.Userinfo
+	// The repo digest of the source image (e.g. ubuntu@sha256:...), or the empty string if the source image has no repo digest.
+	sourceRepoDigest string
+	cmd              []string
+	// The User of the image's config.
+	imageUser string
+	user      *docker.Userinfo
 }
 
 type appVolume struct {
@@ -64,10 +65,13 @@ type appVolumesInitImage struct {
 }
 
 type app struct {
-	composeService                       *config.Service
-	serviceClusterIP                     string
-	imageInfo                            appImageInfo
-	maxObservedPodStatus                 podStatus
+	composeService   *config.Service
+	serviceClusterIP string
+	// The IP of a pod of the app, or the empty string if no pod with an IP has been observed yet.
+	podIP                string
+	imageInfo            appImageInfo
+	maxObservedPodStatus podstatus.Status
+	// The keys of this map are of the form <pod name>/<container name>, since controllers can create multiple pods per app.
 	containersForWhichWeAreStreamingLogs map[string]bool
 	color                                int
 	reporterRow                          *reporter.Row
@@ -75,7 +79,10 @@ type app struct {
 	volumeInitImage                      appVolumesInitImage
 }
 
-func (a *app) hasService() bool {
+// hasClusterIP returns true if the app's Kubernetes Service has a cluster IP. This is the case if and only if the docker compose service has
+// ports (or exposes ports). Otherwise the app's Kubernetes Service is headless, since Kubernetes requires Services with a cluster IP to have
+// ports.
+func (a *app) hasClusterIP() bool {
 	return len(a.composeService.Ports) > 0
 }
 
@@ -109,14 +116,24 @@ type upRunner struct {
 	cfg                   *config.Config
 	completedChannels     []chan interface{}
 	dockerClient          *dockerClient.Client
-	k8sClientset          *kubernetes.Clientset
-	k8sServiceClient      clientV1.ServiceInterface
-	k8sPodClient          clientV1.PodInterface
-	hostAliases           hostAliases
-	localImagesCache      localImagesCache
-	maxServiceNameLength  int
-	opts                  *Options
-	totalVolumeCount      int
+	// The docker config file with the credentials of docker registries, or nil if it has not been loaded yet.
+	dockerConfigFile    *docker.ConfigFile
+	k8sClientset        *kubernetes.Clientset
+	k8sServiceClient    clientV1.ServiceInterface
+	k8sPodClient        clientV1.PodInterface
+	k8sDeploymentClient clientAppsV1.DeploymentInterface
+	k8sJobClient        clientBatchV1.JobInterface
+	k8sIngressClient    clientExtensionsV1beta1.IngressInterface
+	hostAliases         hostAliases
+	// The name of the Secret referenced by the imagePullSecrets of pods, or the empty string if pods do not have imagePullSecrets.
+	imagePullSecretName  string
+	localImagesCache     localImagesCache
+	maxServiceNameLength int
+	opts                 *Options
+	oneOff               *oneOff
+	// If true, images are not pushed to a docker registry (see Pull).
+	skipPush         bool
+	totalVolumeCount int
 }
 
 func (u *upRunner) initKubernetesClientset() error {
@@ -127,24 +144,21 @@ func (u *upRunner) initKubernetesClientset() error {
 	u.k8sClientset = k8sClientset
 	u.k8sServiceClient = u.k8sClientset.CoreV1().Services(u.cfg.Namespace)
 	u.k8sPodClient = u.k8sClientset.CoreV1().Pods(u.cfg.Namespace)
+	u.k8sDeploymentClient = u.k8sClientset.AppsV1().Deployments(u.cfg.Namespace)
+	u.k8sJobClient = u.k8sClientset.BatchV1().Jobs(u.cfg.Namespace)
+	u.k8sIngressClient = u.k8sClientset.ExtensionsV1beta1().Ingresses(u.cfg.Namespace)
 	return nil
 }
 
 func (u *upRunner) initAppsToBeStarted() {
 	u.appsToBeStarted = map[*app]bool{}
-	colorIndex := 0
 	for _, a := range u.apps {
 		if !u.cfg.MatchesFilter(a.composeService) {
 			continue
 		}
 		a.reporterRow = u.opts.Reporter.AddRow(a.name())
 		u.appsToBeStarted[a] = true
-		a.color = appColorPalette[colorIndex]
-		if colorIndex < len(appColorPalette) {
-			colorIndex++
-		} else {
-			colorIndex = 0
-		}
+		a.color = logs.Color(len(u.appsToBeStarted) - 1)
 		if len(a.name()) > u.maxServiceNameLength {
 			u.maxServiceNameLength = len(a.name())
 		}
@@ -250,8 +264,7 @@ func (u *upRunner) getAppVolumeInitImage(a *app) error {
 		a.volumeInitImage.podImage = imageRef
 		a.volumeInitImage.podImagePullPolicy = v1.PullNever
 	} else {
-		a.volumeInitImage.podImage, err = u.pushImage(a.volumeInitImage.sourceImageID, a.composeService.NameEscaped,
-			u.cfg.EnvironmentID+"-volumeinit", "volume init image", a)
+		a.volumeInitImage.podImage, err = u.pushImage(a.volumeInitImage.sourceImageID, tag, "volume init image", a)
 		if err != nil {
 			return err
 		}
@@ -260,26 +273,34 @@ func (u *upRunner) getAppVolumeInitImage(a *app) error {
 	return nil
 }
 
-func (u *upRunner) pushImage(sourceImageID, name, tag, imageDescr string, a *app) (podImage string, err error) {
+func (u *upRunner) pushImage(sourceImageID, tag, imageDescr string, a *app) (podImage string, err error) {
 	pt := a.reporterRow.AddProgressTask("pushing " + imageDescr)
 	defer pt.Done()
 	a.reporterRow.AddStatus(reporter.StatusDockerPush)
 	defer a.reporterRow.RemoveStatus(reporter.StatusDockerPush)
-	imagePush := fmt.Sprintf("%s/%s/%s:%s", u.cfg.ClusterImageStorage.DockerRegistry.Host, u.cfg.Namespace, name, tag)
+	var imagePush string
+	imagePush, err = registry.GetPushImage(u.cfg, a.composeService, tag)
+	if err != nil {
+		return
+	}
 	err = u.dockerClient.ImageTag(u.opts.Context, sourceImageID, imagePush)
 	if err != nil {
 		return
 	}
+	var authConfig *dockerTypes.AuthConfig
+	authConfig, err = registry.GetAuthConfig(u.cfg)
+	if err != nil {
+		return
+	}
 	var digest string
-	registryAuth := docker.EncodeRegistryAuth("unused", u.cfg.KubeConfig.BearerToken)
+	registryAuth := docker.EncodeAuthConfig(authConfig)
 	digest, err = docker.PushImage(u.opts.Context, u.dockerClient, imagePush, registryAuth, func(push *docker.PullOrPush) {
 		pt.Update(push.Progress())
 	})
 	if err != nil {
 		return
 	}
-	podImage = fmt.Sprintf("docker-registry.default.svc:5000/%s/%s@%s", u.cfg.Namespace, name, digest)
-	return
+	return registry.GetPodImage(u.cfg, a.composeService, digest)
 }
 
 func (u *upRunner) getAppVolumeInitImageOnce(a *app) error {
@@ -304,11 +325,20 @@ func (u *upRunner) initApps() {
 }
 
 func (u *upRunner) getAppImageInfo(app *app) error {
+	if lockedImage := u.findLockedImage(app); lockedImage != nil {
+		if lockedImage.PodImage == "" {
+			return u.getAppImageInfoFromLockedSourceImage(app, lockedImage)
+		}
+		return u.getAppImageInfoFromLock(app, lockedImage)
+	}
 	sourceImage := app.composeService.DockerComposeService.Image
 	if sourceImage == "" {
 		return fmt.Errorf("docker compose service %s has no image or its image is the empty string, and building images is not supported",
 			app.name())
 	}
+	if u.isDaemonless() {
+		return u.getAppImageInfoFromRegistry(app, sourceImage)
+	}
 	localImageIDSet, err := u.getLocalImageIDSet()
 	if err != nil {
 		return err
@@ -327,6 +357,7 @@ func (u *upRunner) getAppImageInfo(app *app) error {
 		return err
 	}
 	app.imageInfo.cmd = inspect.Config.Cmd
+	app.imageInfo.imageUser = inspect.Config.User
 	err = u.getAppImageEnsureCorrectPodImage(app, sourceImageRef, sourceImage)
 	if err != nil {
 		return err
@@ -337,7 +368,7 @@ func (u *upRunner) getAppImageInfo(app *app) error {
 	}
 	app.imageInfo.imageHealthcheck = imageHealthcheck
 	if u.opts.RunAsUser {
-		err = u.getAppImageInfoUser(app, &inspect, sourceImage)
+		err = u.getAppImageInfoUser(app, inspect.Config.User, sourceImage)
 	}
 	return err
 }
@@ -354,8 +385,13 @@ func (u *upRunner) getAppImageEnsureCorrectPodImage(a *app, sourceImageRef docke
 		a.imageInfo.podImage = imageRef
 		a.imageInfo.podImagePullPolicy = v1.PullNever
 	case u.cfg.ClusterImageStorage.DockerRegistry != nil:
+		if u.skipPush {
+			// The pod image is determined when the image is pushed.
+			a.imageInfo.podImage = ""
+			return nil
+		}
 		var err error
-		a.imageInfo.podImage, err = u.pushImage(a.imageInfo.sourceImageID, a.composeService.NameEscaped, tag, "image", a)
+		a.imageInfo.podImage, err = u.pushImage(a.imageInfo.sourceImageID, tag, "image", a)
 		if err != nil {
 			return err
 		}
@@ -377,11 +413,14 @@ func (u *upRunner) getAppImageInfoEnsureSourceImageID(sourceImage string, source
 	// We need the image locally always, so we can parse its healthcheck
 	sourceImageNamed, sourceImageIsNamed := sourceImageRef.(dockerRef.Named)
 	a.imageInfo.sourceImageID = resolveLocalImageID(sourceImageRef, localImageIDSet, u.localImagesCache.images)
+	if a.imageInfo.sourceImageID != "" && sourceImageIsNamed {
+		a.imageInfo.sourceRepoDigest = findRepoDigest(u.localImagesCache.images, a.imageInfo.sourceImageID, sourceImageNamed)
+	}
 	if a.imageInfo.sourceImageID == "" {
 		if !sourceImageIsNamed {
 			return fmt.Errorf("could not find image %#v locally, and building images is not supported", sourceImage)
 		}
-		digest, err := u.getAppImageInfoPullImage(sourceImageRef, a)
+		digest, err := u.getAppImageInfoPullImage(sourceImageNamed, a)
 		if err != nil {
 			return err
 		}
@@ -390,6 +429,7 @@ func (u *upRunner) getAppImageInfoEnsureSourceImageID(sourceImage string, source
 		if err != nil {
 			return err
 		}
+		a.imageInfo.sourceRepoDigest = a.imageInfo.podImage
 	}
 	if a.imageInfo.sourceImageID == "" {
 		return fmt.Errorf("could get ID of image %#v, this is either a bug or images were removed by an external process (please try again)",
@@ -399,24 +439,28 @@ func (u *upRunner) getAppImageInfoEnsureSourceImageID(sourceImage string, source
 	return nil
 }
 
-func (u *upRunner) getAppImageInfoPullImage(sourceImageRef dockerRef.Reference, a *app) (string, error) {
+func (u *upRunner) getAppImageInfoPullImage(sourceImageNamed dockerRef.Named, a *app) (string, error) {
+	registryAuth, err := u.getRegistryAuth(dockerRef.Domain(sourceImageNamed))
+	if err != nil {
+		return "", err
+	}
 	pt := a.reporterRow.AddProgressTask("pulling image")
 	defer pt.Done()
 	a.reporterRow.AddStatus(reporter.StatusDockerPull)
 	defer a.reporterRow.RemoveStatus(reporter.StatusDockerPull)
-	return docker.PullImage(u.opts.Context, u.dockerClient, sourceImageRef.String(), "123", func(pull *docker.PullOrPush) {
+	return docker.PullImage(u.opts.Context, u.dockerClient, sourceImageNamed.String(), registryAuth, func(pull *docker.PullOrPush) {
 		pt.Update(pull.Progress())
 	})
 }
 
-func (u *upRunner) getAppImageInfoUser(a *app, inspect *dockerTypes.ImageInspect, sourceImage string) error {
+func (u *upRunner) getAppImageInfoUser(a *app, imageUser, sourceImage string) error {
 	var user *docker.Userinfo
 	var err error
 	userRaw := a.composeService.DockerComposeService.User
 	if userRaw == nil {
-		user, err = docker.ParseUserinfo(inspect.Config.User)
+		user, err = docker.ParseUserinfo(imageUser)
 		if err != nil {
-			return errors.Wrapf(err, "image %#v has an invalid user %#v", sourceImage, inspect.Config.User)
+			return errors.Wrapf(err, "image %#v has an invalid user %#v", sourceImage, imageUser)
 		}
 	} else {
 		user, err = docker.ParseUserinfo(*userRaw)
@@ -427,6 +471,9 @@ func (u *upRunner) getAppImageInfoUser(a *app, inspect *dockerTypes.ImageInspect
 	if user.UID == nil || (user.Group != "" && user.GID == nil) {
 		// TODO https://github.com/kube-compose/kube-compose/issues/70 confirm whether docker and our pod spec will produce the same default
 		// group if a UID is set but no GID
+		if u.isDaemonless() {
+			return errorDaemonlessUserinfo(sourceImage)
+		}
 		err := getUserinfoFromImage(u.opts.Context, u.dockerClient, a.imageInfo.sourceImageID, user)
 		if err != nil {
 			return errors.Wrapf(err, "error getting uid/gid from image %#v", sourceImage)
@@ -445,7 +492,7 @@ func (u *upRunner) getAppImageInfoOnce(app *app) error {
 
 func (u *upRunner) findAppFromObjectMeta(objectMeta *metav1.ObjectMeta) *app {
 	composeService := k8smeta.FindFromObjectMeta(u.cfg, objectMeta)
-	if composeService == nil {
+	if composeService == nil || k8smeta.IsOneOff(objectMeta) {
 		return nil
 	}
 	return u.apps[composeService.Name()]
@@ -456,7 +503,7 @@ func (u *upRunner) waitForServiceClusterIPUpdate(service *v1.Service) (*app, err
 	if app == nil {
 		return nil, nil
 	}
-	if service.Spec.Type != "ClusterIP" {
+	if service.Spec.Type != app.serviceType() {
 		return app, k8smeta.ErrorResourcesModifiedExternally()
 	}
 	app.serviceClusterIP = service.Spec.ClusterIP
@@ -466,7 +513,7 @@ func (u *upRunner) waitForServiceClusterIPUpdate(service *v1.Service) (*app, err
 func (u *upRunner) waitForServiceClusterIPCountRemaining() int {
 	remaining := 0
 	for _, app := range u.apps {
-		if app.hasService() && app.serviceClusterIP == "" {
+		if app.hasClusterIP() && app.serviceClusterIP == "" {
 			remaining++
 		}
 	}
@@ -555,41 +602,78 @@ func (u *upRunner) waitForServiceClusterIP(expected int) error {
 	return u.waitForServiceClusterIPWatch(expected, remaining, watch.ResultChan())
 }
 
+func (u *upRunner) newService(app *app) *v1.Service {
+	service := &v1.Service{
+		Spec: v1.ServiceSpec{
+			Selector: k8smeta.InitCommonLabels(u.cfg, app.composeService, nil),
+			Type:     app.serviceType(),
+		},
+	}
+	if app.hasClusterIP() {
+		dcService := app.composeService.DockerComposeService
+		ports := make([]dockerComposeConfig.PortBinding, 0, len(dcService.Ports)+len(dcService.Expose))
+		ports = append(ports, dcService.Ports...)
+		ports = append(ports, dcService.Expose...)
+		service.Spec.Ports = newServicePorts(ports, service.Spec.Type)
+	} else {
+		service.Spec.ClusterIP = v1.ClusterIPNone
+	}
+	k8smeta.InitObjectMeta(u.cfg, &service.ObjectMeta, app.composeService)
+	service.ObjectMeta.Name = k8smeta.GetK8sServiceName(app.composeService, u.cfg)
+	return service
+}
+
+// createService creates the Kubernetes Service of an app. An existing Service is reused only if it belongs to the environment, since
+// Services are not named after the environment if service discovery is through DNS.
+func (u *upRunner) createService(app *app, service *v1.Service) error {
+	_, err := u.k8sServiceClient.Create(service)
+	switch {
+	case k8sError.IsAlreadyExists(err):
+		existing, err := u.k8sServiceClient.Get(service.ObjectMeta.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if envID := existing.ObjectMeta.Labels[u.cfg.EnvironmentLabel]; envID != u.cfg.EnvironmentID {
+			return fmt.Errorf("k8s service %s already exists and does not belong to this environment (%s=%#v); environments that "+
+				"use DNS service discovery cannot share a namespace (see --create-namespace)", service.ObjectMeta.Name,
+				u.cfg.EnvironmentLabel, envID)
+		}
+		app.newLogEntry().Debugf("k8s service %s already exists", service.ObjectMeta.Name)
+	case err != nil:
+		return err
+	default:
+		app.newLogEntry().Infof("created k8s service %s", service.ObjectMeta.Name)
+	}
+	return nil
+}
+
 func (u *upRunner) createServicesAndGetPodHostAliases() ([]v1.HostAlias, error) {
+	// Ingresses are formatted before any Kubernetes Service is created, so that conflicting ingress hosts fail fast.
+	ingresses, err := u.newIngresses()
+	if err != nil {
+		return nil, err
+	}
 	expectedServiceCount := 0
 	for _, app := range u.apps {
-		if !app.hasService() {
-			continue
+		if app.hasClusterIP() {
+			expectedServiceCount++
 		}
-		expectedServiceCount++
-		servicePorts := make([]v1.ServicePort, len(app.composeService.DockerComposeService.Ports))
-		for i, port := range app.composeService.DockerComposeService.Ports {
-			servicePorts[i] = v1.ServicePort{
-				Name:       fmt.Sprintf("%s%d", port.Protocol, port.Internal),
-				Port:       port.Internal,
-				Protocol:   v1.Protocol(strings.ToUpper(port.Protocol)),
-				TargetPort: intstr.FromInt(int(port.Internal)),
-			}
+		service := u.newService(app)
+		if u.cfg.ServiceDiscovery == config.ServiceDiscoveryDNS && service.ObjectMeta.Name != app.name() {
+			app.newLogEntry().Warnf("the service cannot be resolved by its name %s through DNS, because that is not a valid name of a "+
+				"Kubernetes Service; use %s instead", app.name(), service.ObjectMeta.Name)
 		}
-		service := &v1.Service{
-			Spec: v1.ServiceSpec{
-				Ports:    servicePorts,
-				Selector: k8smeta.InitCommonLabels(u.cfg, app.composeService, nil),
-				Type:     v1.ServiceType("ClusterIP"),
-			},
+		err = u.createService(app, service)
+		if err != nil {
+			return nil, err
 		}
-		k8smeta.InitObjectMeta(u.cfg, &service.ObjectMeta, app.composeService)
-		_, err := u.k8sServiceClient.Create(service)
-		switch {
-		case k8sError.IsAlreadyExists(err):
-			app.newLogEntry().Debugf("k8s service %s already exists", service.ObjectMeta.Name)
-		case err != nil:
+		err = u.createIngress(app, ingresses[app])
+		if err != nil {
 			return nil, err
-		default:
-			app.newLogEntry().Infof("created k8s service %s", service.ObjectMeta.Name)
 		}
 	}
-	if expectedServiceCount == 0 {
+	if expectedServiceCount == 0 || u.cfg.ServiceDiscovery == config.ServiceDiscoveryDNS {
+		// Pods resolve Services through cluster DNS, so there is no need to wait for cluster IPs.
 		return nil, nil
 	}
 	return u.getPodHostAliasesCore(expectedServiceCount)
@@ -603,7 +687,7 @@ func (u *upRunner) getPodHostAliasesCore(expectedServiceCount int) ([]v1.HostAli
 	hostAliases := make([]v1.HostAlias, expectedServiceCount)
 	i := 0
 	for _, app := range u.apps {
-		if app.hasService() {
+		if app.hasClusterIP() {
 			hostAliases[i] = v1.HostAlias{
 				IP: app.serviceClusterIP,
 				Hostnames: []string{
@@ -616,6 +700,49 @@ func (u *upRunner) getPodHostAliasesCore(expectedServiceCount int) ([]v1.HostAli
 	return hostAliases, nil
 }
 
+// appendHeadlessHostAliases returns hostAliases with an additional host alias for each app with a headless Kubernetes Service whose pod IP
+// is known, for the pod of app. Since pods are created after the pods of their dependencies are ready, this makes dependencies without
+// ports resolvable. Host aliases cannot refer to the DNS name of the headless Service, so such a host alias only holds for the current pod
+// of the dependency, and goes stale if that pod is recreated.
+func (u *upRunner) appendHeadlessHostAliases(app *app, hostAliases []v1.HostAlias) []v1.HostAlias {
+	if u.cfg.ServiceDiscovery == config.ServiceDiscoveryDNS {
+		// Headless Services resolve to the IPs of their pods through cluster DNS.
+		return hostAliases
+	}
+	var result []v1.HostAlias
+	for _, headless := range u.apps {
+		if headless.hasClusterIP() {
+			continue
+		}
+		_, isDependency := app.composeService.DockerComposeService.DependsOn[headless.name()]
+		if headless.podIP == "" {
+			if isDependency {
+				app.newLogEntry().Warnf("dependency %s has no ports and none of its pods has an IP, so it cannot be resolved by name "+
+					"(add ports or expose to the service, or use service_discovery dns)", headless.name())
+			}
+			continue
+		}
+		if isDependency {
+			app.newLogEntry().Infof("resolving dependency %s to the IP %s of its current pod, which is not updated if that pod is "+
+				"recreated (add ports or expose to the service, or use service_discovery dns)", headless.name(), headless.podIP)
+		}
+		if result == nil {
+			// Copy hostAliases, since it is shared by all pods.
+			result = append(result, hostAliases...)
+		}
+		result = append(result, v1.HostAlias{
+			IP: headless.podIP,
+			Hostnames: []string{
+				headless.name(),
+			},
+		})
+	}
+	if result == nil {
+		return hostAliases
+	}
+	return result
+}
+
 func (u *upRunner) initLocalImages() error {
 	u.localImagesCache.once.Do(func() {
 		imageSummarySlice, err := u.dockerClient.ImageList(u.opts.Context, dockerTypes.ImageListOptions{
@@ -628,11 +755,10 @@ func (u *upRunner) initLocalImages() error {
 				_ = imageIDSet.Add(goDigest.Digest(imageSummarySlice[i].ID))
 			}
 		}
-		u.localImagesCache = localImagesCache{
-			imageIDSet: imageIDSet,
-			images:     imageSummarySlice,
-			err:        err,
-		}
+		// Assign fields individually, since the once must be preserved.
+		u.localImagesCache.imageIDSet = imageIDSet
+		u.localImagesCache.images = imageSummarySlice
+		u.localImagesCache.err = err
 	})
 	return u.localImagesCache.err
 }
@@ -763,7 +889,8 @@ func (u *upRunner) createPodVolumes(a *app, pod *v1.Pod) error {
 	return nil
 }
 
-func (u *upRunner) createPod(app *app) (*v1.Pod, error) {
+// newPod returns the pod of the app, with overrides and patches applied, without creating it.
+func (u *upRunner) newPod(app *app) (*v1.Pod, error) {
 	err := u.getAppImageInfoOnce(app)
 	if err != nil {
 		return nil, err
@@ -794,6 +921,7 @@ func (u *upRunner) createPod(app *app) (*v1.Pod, error) {
 	if err != nil {
 		return nil, err
 	}
+	hostAliases = u.appendHeadlessHostAliases(app, hostAliases)
 
 	pod := &v1.Pod{
 		Spec: v1.PodSpec{
@@ -819,13 +947,48 @@ func (u *upRunner) createPod(app *app) (*v1.Pod, error) {
 	if err != nil {
 		return nil, err
 	}
+	if u.imagePullSecretName != "" {
+		pod.Spec.ImagePullSecrets = []v1.LocalObjectReference{
+			{
+				Name: u.imagePullSecretName,
+			},
+		}
+	}
+	applyPodOverrides(pod, &app.composeService.PodOverrides)
 	k8smeta.InitObjectMeta(u.cfg, &pod.ObjectMeta, app.composeService)
 
 	err = u.createPodVolumes(app, pod)
 	if err != nil {
 		return nil, err
 	}
+	return applyPodPatches(pod, app.composeService.PodOverrides.Patches)
+}
 
+func (u *upRunner) createPod(app *app) (*v1.Pod, error) {
+	if u.oneOff != nil && u.oneOff.opts.Service == app.composeService {
+		return u.createOneOffPod()
+	}
+	pod, err := u.newPod(app)
+	if err != nil {
+		return nil, err
+	}
+	if app.usesController() {
+		err = u.createController(app, pod)
+		if err != nil {
+			return nil, err
+		}
+		if app.needsToBeReady() {
+			u.appsThatNeedToBeReady[app] = true
+		} else {
+			// No pods will be created for the app, so the depends_on conditions of its dependents are satisfied immediately.
+			u.setAppMaxObservedPodStatus(app, podstatus.StatusReady)
+		}
+		return nil, nil
+	}
+	if replicas := app.replicas(); replicas != nil && *replicas != 1 {
+		app.newLogEntry().Warn("ignoring deploy.replicas because the service is run as a bare pod (see " +
+			"https://github.com/kube-compose/kube-compose#workloads)")
+	}
 	podServer, err := u.k8sPodClient.Create(pod)
 	if k8sError.IsAlreadyExists(err) {
 		app.newLogEntry().Debugf("pod %s already exists", pod.ObjectMeta.Name)
@@ -837,56 +1000,6 @@ func (u *upRunner) createPod(app *app) (*v1.Pod, error) {
 	return podServer, nil
 }
 
-func isPodReady(pod *v1.Pod) bool {
-	for _, condition := range pod.Status.Conditions {
-		if condition.Type == v1.PodReady && condition.Status == v1.ConditionTrue {
-			return true
-		}
-	}
-	return false
-}
-
-func parsePodStatus(pod *v1.Pod) (podStatus, error) {
-	if isPodReady(pod) {
-		return podStatusReady, nil
-	}
-	runningCount := 0
-	for _, containerStatus := range pod.Status.ContainerStatuses {
-		t := containerStatus.State.Terminated
-		if t != nil {
-			return parsePodStatusTerminatedContainer(pod.ObjectMeta.Name, containerStatus.Name, t)
-		}
-		if w := containerStatus.State.Waiting; w != nil && w.Reason == "ErrImagePull" {
-			return podStatusOther, fmt.Errorf("container %s of pod %s could not pull image: %s",
-				containerStatus.Name,
-				pod.ObjectMeta.Name,
-				w.Message,
-			)
-		}
-		if containerStatus.State.Running != nil {
-			runningCount++
-		}
-	}
-	if runningCount == len(pod.Status.ContainerStatuses) {
-		return podStatusStarted, nil
-	}
-	return podStatusOther, nil
-}
-
-func parsePodStatusTerminatedContainer(podName, containerName string, t *v1.ContainerStateTerminated) (podStatus, error) {
-	if t.Reason != "Completed" {
-		return podStatusOther, fmt.Errorf("container %s of pod %s terminated abnormally (code=%d,signal=%d,reason=%s): %s",
-			containerName,
-			podName,
-			t.ExitCode,
-			t.Signal,
-			t.Reason,
-			t.Message,
-		)
-	}
-	return podStatusCompleted, nil
-}
-
 func (u *upRunner) updateAppMaxObservedPodStatus(pod *v1.Pod) error {
 
 	app := u.findAppFromObjectMeta(&pod.ObjectMeta)
@@ -900,9 +1013,10 @@ func (u *upRunner) updateAppMaxObservedPodStatus(pod *v1.Pod) error {
 	//				start streaming logs for the container
 	if !u.opts.Detach && u.cfg.MatchesFilterDirectly(app.composeService) {
 		for _, containerStatus := range pod.Status.ContainerStatuses {
-			_, ok := app.containersForWhichWeAreStreamingLogs[containerStatus.Name]
+			key := pod.ObjectMeta.Name + "/" + containerStatus.Name
+			_, ok := app.containersForWhichWeAreStreamingLogs[key]
 			if !ok && containerStatus.State.Running != nil {
-				app.containersForWhichWeAreStreamingLogs[containerStatus.Name] = true
+				app.containersForWhichWeAreStreamingLogs[key] = true
 				getPodLogOptions := &v1.PodLogOptions{
 					Follow:    true,
 					Container: containerStatus.Name,
@@ -913,7 +1027,10 @@ func (u *upRunner) updateAppMaxObservedPodStatus(pod *v1.Pod) error {
 			}
 		}
 	}
-	s, err := parsePodStatus(pod)
+	if pod.Status.PodIP != "" && pod.ObjectMeta.DeletionTimestamp == nil {
+		app.podIP = pod.Status.PodIP
+	}
+	s, err := podstatus.Parse(pod)
 	if err != nil {
 		if app.reporterRow != nil {
 			app.reporterRow.AddStatus(&reporter.Status{
@@ -931,13 +1048,13 @@ func (u *upRunner) updateAppMaxObservedPodStatus(pod *v1.Pod) error {
 	return nil
 }
 
-func (u *upRunner) setAppMaxObservedPodStatus(app *app, s podStatus) {
+func (u *upRunner) setAppMaxObservedPodStatus(app *app, s podstatus.Status) {
 	app.maxObservedPodStatus = s
 	if app.reporterRow != nil {
 		switch {
-		case s == podStatusStarted:
+		case s == podstatus.StatusStarted:
 			app.reporterRow.AddStatus(reporter.StatusRunning)
-		case s >= podStatusReady:
+		case s >= podstatus.StatusReady:
 			app.reporterRow.RemoveStatus(reporter.StatusRunning)
 			app.reporterRow.AddStatus(reporter.StatusReady)
 		}
@@ -955,7 +1072,7 @@ func (u *upRunner) streamPodLogs(pod *v1.Pod, completedChannel chan interface{},
 	defer util.CloseAndLogError(bodyReader)
 	scanner := bufio.NewScanner(bodyReader)
 	for scanner.Scan() {
-		log.Infof("\x1b[%dm%-*s|\x1b[0m %s", a.color, u.maxServiceNameLength+3, a.name(), scanner.Text())
+		log.Info(logs.FormatLine(a.color, u.maxServiceNameLength+3, a.name(), scanner.Text()))
 	}
 	if err = scanner.Err(); err != nil {
 		log.Error(err)
@@ -964,17 +1081,29 @@ func (u *upRunner) streamPodLogs(pod *v1.Pod, completedChannel chan interface{},
 }
 
 func (u *upRunner) createPodsIfNeeded() error {
+	// Creating a pod can satisfy the depends_on conditions of other apps without any pod events (see createPod), so repeat until no pod
+	// is created.
+	for {
+		created, err := u.createPodsIfNeededOnce()
+		if err != nil || !created {
+			return err
+		}
+	}
+}
+
+func (u *upRunner) createPodsIfNeededOnce() (bool, error) {
+	created := false
 	for app1 := range u.appsToBeStarted {
 		createPod := true
 		for name, healthiness := range app1.composeService.DockerComposeService.DependsOn {
 			composeService := u.cfg.Services[name]
 			app2 := u.apps[composeService.Name()]
 			if healthiness == dockerComposeConfig.ServiceHealthy {
-				if app2.maxObservedPodStatus != podStatusReady {
+				if app2.maxObservedPodStatus != podstatus.StatusReady {
 					createPod = false
 				}
 			} else {
-				if app2.maxObservedPodStatus != podStatusStarted && app2.maxObservedPodStatus != podStatusReady {
+				if app2.maxObservedPodStatus != podstatus.StatusStarted && app2.maxObservedPodStatus != podstatus.StatusReady {
 					createPod = false
 				}
 			}
@@ -983,12 +1112,13 @@ func (u *upRunner) createPodsIfNeeded() error {
 			app1.newLogEntry().Debugf(u.formatCreatePodReason(app1))
 			_, err := u.createPod(app1)
 			if err != nil {
-				return err
+				return false, err
 			}
 			delete(u.appsToBeStarted, app1)
+			created = true
 		}
 	}
-	return nil
+	return created, nil
 }
 
 func (u *upRunner) formatCreatePodReason(app1 *app) string {
@@ -1051,17 +1181,31 @@ func (u *upRunner) run() error {
 	u.initApps()
 	u.initAppsToBeStarted()
 	u.initVolumeInfo()
+	u.warnIfNotDaemonless()
 	err := u.initKubernetesClientset()
 	if err != nil {
 		return err
 	}
-	// Initialize docker client
-	var dc *dockerClient.Client
-	dc, err = dockerClient.NewEnvClient()
+	if u.opts.RemoveOrphans {
+		err = down.RemoveOrphans(u.cfg)
+		if err != nil {
+			return err
+		}
+	}
+	err = u.createEphemeralNamespace()
+	if err != nil {
+		return err
+	}
+	err = u.initDockerClient()
 	if err != nil {
 		return err
 	}
-	u.dockerClient = dc
+	if u.opts.ImagePullSecret {
+		err = u.createImagePullSecret()
+		if err != nil {
+			return err
+		}
+	}
 
 	for app := range u.appsToBeStarted {
 		// Begin pulling and pushing images immediately...
@@ -1114,7 +1258,8 @@ func (u *upRunner) runWatchPodsEvent(event *k8swatch.Event) error {
 	case k8swatch.Deleted:
 		pod := event.Object.(*v1.Pod)
 		app := u.findAppFromObjectMeta(&pod.ObjectMeta)
-		if app != nil {
+		// Pods of controllers can be deleted (e.g. when a node is drained), in which case the controller will replace them.
+		if app != nil && !app.usesController() {
 			return k8smeta.ErrorResourcesModifiedExternally()
 		}
 	default:
@@ -1157,9 +1302,13 @@ func (u *upRunner) runWatchPods(resourceVersion string) error {
 }
 
 func (u *upRunner) checkIfPodsReady() bool {
+	if u.oneOff != nil && u.oneOff.pod == nil {
+		// The one-off pod is created once its depends_on conditions are satisfied.
+		return false
+	}
 	allPodsReady := true
 	for app := range u.appsThatNeedToBeReady {
-		if app.maxObservedPodStatus < podStatusReady {
+		if app.maxObservedPodStatus < podstatus.StatusReady {
 			allPodsReady = false
 		}
 	}
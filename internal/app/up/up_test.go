@@ -5,6 +5,10 @@ import (
 
 	"github.com/kube-compose/kube-compose/internal/app/config"
 	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
 	"k8s.io/client-go/rest"
 )
 
@@ -81,14 +85,14 @@ func TestAppName(t *testing.T) {
 	}
 }
 
-func TestAppHasService_False(t *testing.T) {
+func TestAppHasClusterIP_False(t *testing.T) {
 	app := newTestApp("a")
-	if app.hasService() {
+	if app.hasClusterIP() {
 		t.Fail()
 	}
 }
 
-func TestAppHasService_True(t *testing.T) {
+func TestAppHasClusterIP_True(t *testing.T) {
 	app := newTestApp("a")
 	app.composeService.Ports = []config.Port{
 		{
@@ -96,7 +100,7 @@ func TestAppHasService_True(t *testing.T) {
 			Protocol: "tcp",
 		},
 	}
-	if !app.hasService() {
+	if !app.hasClusterIP() {
 		t.Fail()
 	}
 }
@@ -130,3 +134,158 @@ func TestFormatCreatePodReason(t *testing.T) {
 		t.Error(s)
 	}
 }
+
+func TestUpRunnerNewService_Headless(t *testing.T) {
+	u := &upRunner{
+		cfg: newTestConfig(),
+	}
+	service := u.newService(newTestApp("a"))
+	if service.Spec.ClusterIP != v1.ClusterIPNone || len(service.Spec.Ports) != 0 || service.Spec.Type != v1.ServiceTypeClusterIP {
+		t.Error(service.Spec)
+	}
+}
+
+func TestUpRunnerNewService_Expose(t *testing.T) {
+	u := &upRunner{
+		cfg: newTestConfig(),
+	}
+	app := newTestApp("a")
+	app.composeService.Ports = []config.Port{
+		{
+			Port:     8080,
+			Protocol: "tcp",
+		},
+	}
+	app.composeService.DockerComposeService.Expose = []dockerComposeConfig.PortBinding{
+		{
+			Internal:    8080,
+			ExternalMin: -1,
+			ExternalMax: -1,
+			Protocol:    "tcp",
+		},
+	}
+	service := u.newService(app)
+	if service.Spec.ClusterIP != "" || len(service.Spec.Ports) != 1 || service.Spec.Ports[0].Port != 8080 {
+		t.Error(service.Spec)
+	}
+}
+
+func TestUpRunnerAppendHeadlessHostAliases(t *testing.T) {
+	u := &upRunner{
+		apps: map[string]*app{},
+		cfg:  newTestConfig(),
+	}
+	a := newTestApp("a")
+	a.podIP = "10.0.0.1"
+	u.apps["a"] = a
+	b := newTestApp("b")
+	u.apps["b"] = b
+	c := newTestApp("c")
+	shared := []v1.HostAlias{
+		{
+			IP:        "10.1.0.1",
+			Hostnames: []string{"c"},
+		},
+	}
+	hostAliases := u.appendHeadlessHostAliases(c, shared)
+	if len(hostAliases) != 2 || hostAliases[1].IP != "10.0.0.1" || hostAliases[1].Hostnames[0] != "a" {
+		t.Error(hostAliases)
+	}
+	if len(shared) != 1 {
+		t.Fail()
+	}
+	u.cfg.ServiceDiscovery = config.ServiceDiscoveryDNS
+	if hostAliases = u.appendHeadlessHostAliases(c, shared); len(hostAliases) != 1 {
+		t.Error(hostAliases)
+	}
+	u.cfg.ServiceDiscovery = config.ServiceDiscoveryHostAliases
+	a.podIP = ""
+	if hostAliases = u.appendHeadlessHostAliases(c, shared); len(hostAliases) != 1 {
+		t.Error(hostAliases)
+	}
+}
+
+func TestUpRunnerNewService_DNS(t *testing.T) {
+	u := &upRunner{
+		cfg: newTestConfig(),
+	}
+	u.cfg.EnvironmentID = "myenv"
+	u.cfg.ServiceDiscovery = config.ServiceDiscoveryDNS
+	service := u.newService(newTestApp("a"))
+	if service.ObjectMeta.Name != "a" {
+		t.Error(service.ObjectMeta.Name)
+	}
+}
+
+type testServiceClient struct {
+	clientV1.ServiceInterface
+	services map[string]*v1.Service
+}
+
+func (c *testServiceClient) Create(service *v1.Service) (*v1.Service, error) {
+	if c.services[service.ObjectMeta.Name] != nil {
+		return nil, k8sError.NewAlreadyExists(v1.Resource("services"), service.ObjectMeta.Name)
+	}
+	c.services[service.ObjectMeta.Name] = service
+	return service, nil
+}
+
+func (c *testServiceClient) Get(name string, options metav1.GetOptions) (*v1.Service, error) {
+	if service := c.services[name]; service != nil {
+		return service, nil
+	}
+	return nil, k8sError.NewNotFound(v1.Resource("services"), name)
+}
+
+func newTestDNSUpRunner(envID string, serviceClient *testServiceClient) *upRunner {
+	u := &upRunner{
+		cfg:              newTestConfig(),
+		k8sServiceClient: serviceClient,
+	}
+	u.cfg.EnvironmentLabel = "env"
+	u.cfg.EnvironmentID = envID
+	u.cfg.ServiceDiscovery = config.ServiceDiscoveryDNS
+	return u
+}
+
+func TestUpRunnerCreateService_DNSSameEnvironment(t *testing.T) {
+	serviceClient := &testServiceClient{
+		services: map[string]*v1.Service{},
+	}
+	a := newTestApp("a")
+	for i := 0; i < 2; i++ {
+		u := newTestDNSUpRunner("env1", serviceClient)
+		if err := u.createService(a, u.newService(a)); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestUpRunnerCreateService_DNSOtherEnvironment(t *testing.T) {
+	serviceClient := &testServiceClient{
+		services: map[string]*v1.Service{},
+	}
+	a := newTestApp("a")
+	u := newTestDNSUpRunner("env1", serviceClient)
+	if err := u.createService(a, u.newService(a)); err != nil {
+		t.Fatal(err)
+	}
+	u = newTestDNSUpRunner("env2", serviceClient)
+	if err := u.createService(a, u.newService(a)); err == nil {
+		t.Fail()
+	}
+}
+
+func TestUpRunnerNewNamespace(t *testing.T) {
+	u := &upRunner{
+		cfg: &config.Config{
+			EnvironmentID:    "123",
+			EnvironmentLabel: "env",
+			Namespace:        "ci-123",
+		},
+	}
+	namespace := u.newNamespace()
+	if namespace.ObjectMeta.Name != "ci-123" || namespace.ObjectMeta.Labels["env"] != "123" {
+		t.Error(namespace.ObjectMeta)
+	}
+}
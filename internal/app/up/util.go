@@ -8,6 +8,7 @@ import (
 	"math"
 	"os"
 	"path"
+	"strings"
 	"time"
 
 	log "github.com/Sirupsen/logrus"
@@ -288,6 +289,24 @@ func resolveLocalImageAfterPull(ctx context.Context, dc *dockerClient.Client, na
 	return "", "", nil
 }
 
+// findRepoDigest returns a repo digest of the image with the specified ID in the repository of named, or the empty string if there is no
+// such repo digest.
+func findRepoDigest(localImagesCache []dockerTypes.ImageSummary, imageID string, named dockerRef.Named) string {
+	// docker images returns RepoDigests as a familiar name with a digest
+	prefix := dockerRef.FamiliarName(named) + "@"
+	for i := 0; i < len(localImagesCache); i++ {
+		if localImagesCache[i].ID != imageID {
+			continue
+		}
+		for _, repoDigest := range localImagesCache[i].RepoDigests {
+			if strings.HasPrefix(repoDigest, prefix) {
+				return repoDigest
+			}
+		}
+	}
+	return ""
+}
+
 func getTag(ref dockerRef.Reference) string {
 	refWithTag, ok := ref.(hasTag)
 	if !ok {
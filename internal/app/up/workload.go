@@ -0,0 +1,110 @@
+package up
+
+import (
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	appsV1 "k8s.io/api/apps/v1"
+	batchV1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	k8sError "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// usesController returns true if the pods of the app are managed by a Deployment or Job, and false if the app is run as a bare Pod.
+func (a *app) usesController() bool {
+	return a.composeService.Workload == config.WorkloadController
+}
+
+// isOneShot returns true if the app should be run by a Job (as opposed to a Deployment). This is the case if the docker compose service
+// explicitly does not want its containers to be restarted after they exit successfully.
+func (a *app) isOneShot() bool {
+	switch a.composeService.DockerComposeService.Restart {
+	case "no", "on-failure":
+		return true
+	}
+	return false
+}
+
+// replicas returns the number of replicas based on deploy.replicas of the docker compose service, or nil if it is not set.
+func (a *app) replicas() *int32 {
+	if a.composeService.DockerComposeService.Replicas == nil {
+		return nil
+	}
+	replicas := int32(*a.composeService.DockerComposeService.Replicas)
+	return &replicas
+}
+
+// needsToBeReady returns false if and only if no pods will be created for the app, in which case up should not wait for the app.
+func (a *app) needsToBeReady() bool {
+	replicas := a.replicas()
+	return !a.usesController() || replicas == nil || *replicas > 0
+}
+
+func newPodTemplateSpec(pod *v1.Pod) v1.PodTemplateSpec {
+	template := v1.PodTemplateSpec{
+		ObjectMeta: *pod.ObjectMeta.DeepCopy(),
+		Spec:       *pod.Spec.DeepCopy(),
+	}
+	// Names of pods are generated by the controller.
+	template.ObjectMeta.Name = ""
+	return template
+}
+
+// createController creates the Deployment or Job that manages the pods of an app. The pods created by the controller will have the same
+// labels and annotations as pod, so that they can be tracked the same way as bare pods.
+func (u *upRunner) createController(app *app, pod *v1.Pod) error {
+	if app.isOneShot() {
+		return u.createJob(app, pod)
+	}
+	return u.createDeployment(app, pod)
+}
+
+func (u *upRunner) createDeployment(app *app, pod *v1.Pod) error {
+	deployment := &appsV1.Deployment{
+		Spec: appsV1.DeploymentSpec{
+			Replicas: app.replicas(),
+			Selector: &metav1.LabelSelector{
+				MatchLabels: k8smeta.InitCommonLabels(u.cfg, app.composeService, nil),
+			},
+			Template: newPodTemplateSpec(pod),
+		},
+	}
+	// Deployments only support restart policy Always.
+	deployment.Spec.Template.Spec.RestartPolicy = v1.RestartPolicyAlways
+	k8smeta.InitObjectMeta(u.cfg, &deployment.ObjectMeta, app.composeService)
+	_, err := u.k8sDeploymentClient.Create(deployment)
+	switch {
+	case k8sError.IsAlreadyExists(err):
+		app.newLogEntry().Debugf("deployment %s already exists", deployment.ObjectMeta.Name)
+	case err != nil:
+		return err
+	default:
+		app.newLogEntry().Debugf("created deployment %s", deployment.ObjectMeta.Name)
+	}
+	return nil
+}
+
+func (u *upRunner) createJob(app *app, pod *v1.Pod) error {
+	job := &batchV1.Job{
+		Spec: batchV1.JobSpec{
+			Completions: app.replicas(),
+			Parallelism: app.replicas(),
+			Template:    newPodTemplateSpec(pod),
+		},
+	}
+	if job.Spec.Template.Spec.RestartPolicy == v1.RestartPolicyNever {
+		// Similar to docker compose, do not retry containers that exit with a non-zero code if the restart policy is "no".
+		job.Spec.BackoffLimit = new(int32)
+	}
+	k8smeta.InitObjectMeta(u.cfg, &job.ObjectMeta, app.composeService)
+	_, err := u.k8sJobClient.Create(job)
+	switch {
+	case k8sError.IsAlreadyExists(err):
+		app.newLogEntry().Debugf("job %s already exists", job.ObjectMeta.Name)
+	case err != nil:
+		return err
+	default:
+		app.newLogEntry().Debugf("created job %s", job.ObjectMeta.Name)
+	}
+	return nil
+}
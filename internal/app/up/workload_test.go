@@ -0,0 +1,67 @@
+package up
+
+import (
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAppUsesController_False(t *testing.T) {
+	app := newTestApp("a")
+	if app.usesController() {
+		t.Fail()
+	}
+}
+
+func TestAppUsesController_True(t *testing.T) {
+	app := newTestApp("a")
+	app.composeService.Workload = config.WorkloadController
+	if !app.usesController() {
+		t.Fail()
+	}
+}
+
+func TestAppIsOneShot(t *testing.T) {
+	if !newTestApp("a").isOneShot() || newTestApp("b").isOneShot() || !newTestApp("c").isOneShot() || newTestApp("d").isOneShot() {
+		t.Fail()
+	}
+}
+
+func TestAppReplicas(t *testing.T) {
+	app := newTestApp("a")
+	if app.replicas() != nil {
+		t.Fail()
+	}
+	replicas := uint(3)
+	app.composeService.DockerComposeService.Replicas = &replicas
+	if r := app.replicas(); r == nil || *r != 3 {
+		t.Fail()
+	}
+}
+
+func TestAppNeedsToBeReady_ZeroReplicas(t *testing.T) {
+	app := newTestApp("a")
+	app.composeService.Workload = config.WorkloadController
+	replicas := uint(0)
+	app.composeService.DockerComposeService.Replicas = &replicas
+	if app.needsToBeReady() {
+		t.Fail()
+	}
+}
+
+func TestNewPodTemplateSpec(t *testing.T) {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "a-123",
+			Labels: map[string]string{
+				"app": "a",
+			},
+		},
+	}
+	template := newPodTemplateSpec(pod)
+	if template.ObjectMeta.Name != "" || template.ObjectMeta.Labels["app"] != "a" {
+		t.Fail()
+	}
+}
@@ -0,0 +1,187 @@
+package wait
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/Sirupsen/logrus"
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/app/podstatus"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8swatch "k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	clientV1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// Options are the options of the wait command.
+type Options struct {
+	// The status that the pods of the docker compose services must reach. Higher statuses also satisfy the condition, for example a
+	// completed pod is also considered to be ready.
+	Condition podstatus.Status
+	// If positive, the maximum duration to wait.
+	Timeout time.Duration
+}
+
+// ParseCondition parses one of "ready", "started" and "completed".
+func ParseCondition(condition string) (podstatus.Status, error) {
+	switch condition {
+	case podstatus.StatusReadyString:
+		return podstatus.StatusReady, nil
+	case podstatus.StatusStartedString:
+		return podstatus.StatusStarted, nil
+	case podstatus.StatusCompletedString:
+		return podstatus.StatusCompleted, nil
+	}
+	return podstatus.StatusOther, fmt.Errorf("invalid condition %#v, must be one of %s, %s and %s", condition, podstatus.StatusReadyString,
+		podstatus.StatusStartedString, podstatus.StatusCompletedString)
+}
+
+type waitRunner struct {
+	cfg          *config.Config
+	k8sClientset *kubernetes.Clientset
+	k8sPodClient clientV1.PodInterface
+	// The maximum observed status of the pods of each docker compose service, similar to the up command.
+	maxObservedPodStatus map[*config.Service]podstatus.Status
+	opts                 *Options
+}
+
+func (w *waitRunner) initKubernetesClientset() error {
+	k8sClientset, err := kubernetes.NewForConfig(w.cfg.KubeConfig)
+	if err != nil {
+		return err
+	}
+	w.k8sClientset = k8sClientset
+	w.k8sPodClient = w.k8sClientset.CoreV1().Pods(w.cfg.Namespace)
+	return nil
+}
+
+func (w *waitRunner) updateMaxObservedPodStatus(pod *v1.Pod) error {
+	composeService := k8smeta.FindFromObjectMeta(w.cfg, &pod.ObjectMeta)
+	if composeService == nil || !w.cfg.MatchesFilterDirectly(composeService) || k8smeta.IsOneOff(&pod.ObjectMeta) {
+		return nil
+	}
+	s, err := podstatus.Parse(pod)
+	if err != nil {
+		return fmt.Errorf("service %s: %v", composeService.Name(), err)
+	}
+	if s > w.maxObservedPodStatus[composeService] {
+		w.maxObservedPodStatus[composeService] = s
+		log.Debugf("service %s: pod status %s", composeService.Name(), &s)
+	}
+	return nil
+}
+
+// getPending returns the docker compose services whose pods have not yet reached the condition, and their status, sorted by name.
+func (w *waitRunner) getPending() []string {
+	var pending []string
+	for _, composeService := range w.cfg.Services {
+		if !w.cfg.MatchesFilterDirectly(composeService) {
+			continue
+		}
+		s := w.maxObservedPodStatus[composeService]
+		if s < w.opts.Condition {
+			pending = append(pending, fmt.Sprintf("%s (%s)", composeService.Name(), &s))
+		}
+	}
+	sort.Strings(pending)
+	return pending
+}
+
+func (w *waitRunner) list(listOptions metav1.ListOptions) (string, error) {
+	podList, err := w.k8sPodClient.List(listOptions)
+	if err != nil {
+		return "", err
+	}
+	for i := 0; i < len(podList.Items); i++ {
+		err = w.updateMaxObservedPodStatus(&podList.Items[i])
+		if err != nil {
+			return "", err
+		}
+	}
+	return podList.ResourceVersion, nil
+}
+
+// watch processes pod events until the condition is met. Returns false if the watch was closed by the server before the condition was
+// met.
+func (w *waitRunner) watch(listOptions metav1.ListOptions, timeoutChannel <-chan time.Time) (bool, error) {
+	listOptions.Watch = true
+	watch, err := w.k8sPodClient.Watch(listOptions)
+	if err != nil {
+		return false, err
+	}
+	defer watch.Stop()
+	eventChannel := watch.ResultChan()
+	for {
+		select {
+		case event, ok := <-eventChannel:
+			if !ok {
+				return false, nil
+			}
+			switch event.Type {
+			case k8swatch.Added, k8swatch.Modified:
+				err = w.updateMaxObservedPodStatus(event.Object.(*v1.Pod))
+				if err != nil {
+					return false, err
+				}
+			case k8swatch.Deleted:
+			default:
+				return false, fmt.Errorf("got unexpected error event from channel: %+v", event.Object)
+			}
+			if len(w.getPending()) == 0 {
+				return true, nil
+			}
+		case <-timeoutChannel:
+			return false, fmt.Errorf("timed out waiting for services to be %s: %s", &w.opts.Condition, strings.Join(w.getPending(), ", "))
+		}
+	}
+}
+
+func (w *waitRunner) run() error {
+	err := w.initKubernetesClientset()
+	if err != nil {
+		return err
+	}
+	var timeoutChannel <-chan time.Time
+	if w.opts.Timeout > 0 {
+		timeoutChannel = time.After(w.opts.Timeout)
+	}
+	listOptions := metav1.ListOptions{
+		LabelSelector: w.cfg.EnvironmentLabel + "=" + w.cfg.EnvironmentID,
+	}
+	for {
+		// List again whenever the watch is closed by the server, since events may have been missed.
+		listOptions.ResourceVersion = ""
+		listOptions.ResourceVersion, err = w.list(listOptions)
+		if err != nil {
+			return err
+		}
+		if len(w.getPending()) == 0 {
+			break
+		}
+		var done bool
+		done, err = w.watch(listOptions, timeoutChannel)
+		if err != nil {
+			return err
+		}
+		if done {
+			break
+		}
+	}
+	log.Infof("services %s", &w.opts.Condition)
+	return nil
+}
+
+// Run waits until the pods of the docker compose services that match the filter of cfg directly reach the condition. An error is returned
+// if a pod fails or the timeout expires.
+func Run(cfg *config.Config, opts *Options) error {
+	w := &waitRunner{
+		cfg:                  cfg,
+		maxObservedPodStatus: map[*config.Service]podstatus.Status{},
+		opts:                 opts,
+	}
+	return w.run()
+}
@@ -0,0 +1,163 @@
+package wait
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/kube-compose/kube-compose/internal/app/config"
+	"github.com/kube-compose/kube-compose/internal/app/k8smeta"
+	"github.com/kube-compose/kube-compose/internal/app/podstatus"
+	dockerComposeConfig "github.com/kube-compose/kube-compose/pkg/docker/compose/config"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestParseCondition_Success(t *testing.T) {
+	expected := map[string]podstatus.Status{
+		"ready":     podstatus.StatusReady,
+		"started":   podstatus.StatusStarted,
+		"completed": podstatus.StatusCompleted,
+	}
+	for condition, expectedStatus := range expected {
+		status, err := ParseCondition(condition)
+		if err != nil || status != expectedStatus {
+			t.Error(condition)
+		}
+	}
+}
+
+func TestParseCondition_Invalid(t *testing.T) {
+	_, err := ParseCondition("other")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func newTestWaitRunner(condition podstatus.Status) *waitRunner {
+	cfg := &config.Config{}
+	a := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "a",
+	})
+	b := cfg.AddService(&dockerComposeConfig.Service{
+		Name: "b",
+	})
+	cfg.AddService(&dockerComposeConfig.Service{
+		Name: "c",
+	})
+	cfg.AddToFilter(a)
+	cfg.AddToFilter(b)
+	return &waitRunner{
+		cfg:                  cfg,
+		maxObservedPodStatus: map[*config.Service]podstatus.Status{},
+		opts: &Options{
+			Condition: condition,
+		},
+	}
+}
+
+func newTestPod(service string, ready bool) *v1.Pod {
+	pod := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: service + "-env1",
+			Annotations: map[string]string{
+				k8smeta.AnnotationName: service,
+			},
+		},
+		Status: v1.PodStatus{
+			ContainerStatuses: []v1.ContainerStatus{
+				{
+					State: v1.ContainerState{
+						Running: &v1.ContainerStateRunning{},
+					},
+				},
+			},
+		},
+	}
+	if ready {
+		pod.Status.Conditions = []v1.PodCondition{
+			{Type: v1.PodReady, Status: v1.ConditionTrue},
+		}
+	}
+	return pod
+}
+
+func TestWaitRunnerGetPending(t *testing.T) {
+	w := newTestWaitRunner(podstatus.StatusReady)
+	for _, pod := range []*v1.Pod{newTestPod("a", false), newTestPod("c", false)} {
+		err := w.updateMaxObservedPodStatus(pod)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	pending := w.getPending()
+	if !reflect.DeepEqual(pending, []string{"a (started)", "b (other)"}) {
+		t.Error(pending)
+	}
+	for _, pod := range []*v1.Pod{newTestPod("a", true), newTestPod("b", true), newTestPod("a", false)} {
+		err := w.updateMaxObservedPodStatus(pod)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	pending = w.getPending()
+	if len(pending) != 0 {
+		t.Error(pending)
+	}
+}
+
+func TestWaitRunnerGetPending_Started(t *testing.T) {
+	w := newTestWaitRunner(podstatus.StatusStarted)
+	for _, pod := range []*v1.Pod{newTestPod("a", false), newTestPod("b", true)} {
+		err := w.updateMaxObservedPodStatus(pod)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	pending := w.getPending()
+	if len(pending) != 0 {
+		t.Error(pending)
+	}
+}
+
+func TestWaitRunnerGetPending_PendingPod(t *testing.T) {
+	w := newTestWaitRunner(podstatus.StatusStarted)
+	pod := newTestPod("a", false)
+	pod.Status.Phase = v1.PodPending
+	pod.Status.ContainerStatuses = nil
+	err := w.updateMaxObservedPodStatus(pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pending := w.getPending()
+	if !reflect.DeepEqual(pending, []string{"a (other)", "b (other)"}) {
+		t.Error(pending)
+	}
+}
+
+func TestWaitRunnerUpdateMaxObservedPodStatus_Failed(t *testing.T) {
+	w := newTestWaitRunner(podstatus.StatusReady)
+	pod := newTestPod("a", false)
+	pod.Status.ContainerStatuses[0].State = v1.ContainerState{
+		Terminated: &v1.ContainerStateTerminated{
+			ExitCode: 1,
+			Reason:   "Error",
+		},
+	}
+	err := w.updateMaxObservedPodStatus(pod)
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestWaitRunnerUpdateMaxObservedPodStatus_IgnoresOneOff(t *testing.T) {
+	w := newTestWaitRunner(podstatus.StatusReady)
+	pod := newTestPod("a", true)
+	pod.ObjectMeta.Annotations[k8smeta.AnnotationOneOff] = "true"
+	err := w.updateMaxObservedPodStatus(pod)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.maxObservedPodStatus) != 0 {
+		t.Fail()
+	}
+}
@@ -0,0 +1,209 @@
+package docker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+	"github.com/pkg/errors"
+)
+
+// DockerHubConfigKey is the key of Docker Hub in the auths of a docker config file.
+const DockerHubConfigKey = "https://index.docker.io/v1/"
+
+// ConfigFile is the subset of a docker config file (e.g. ~/.docker/config.json) that holds credentials.
+type ConfigFile struct {
+	Auths map[string]*ConfigAuth `json:"auths"`
+	// The credential helper of each registry host, for example {"gcr.io": "gcloud"} denotes the program docker-credential-gcloud.
+	CredHelpers map[string]string `json:"credHelpers,omitempty"`
+	// The credential helper of registry hosts that do not have a credential helper in CredHelpers.
+	CredsStore string `json:"credsStore,omitempty"`
+}
+
+// credentialHelperNotFound is the output of credential helpers if they do not have credentials of a registry.
+const credentialHelperNotFound = "credentials not found in native keychain"
+
+// credentialHelperTokenUsername is the username returned by credential helpers to denote that the secret is an identity token.
+const credentialHelperTokenUsername = "<token>"
+
+// runCredentialHelper runs the get command of a credential helper, see https://github.com/docker/docker-credential-helpers. It is a
+// variable so that tests can mock it.
+var runCredentialHelper = func(helper, serverURL string) ([]byte, error) {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(serverURL)
+	return cmd.Output()
+}
+
+// ConfigAuth is an entry of the auths of a docker config file.
+type ConfigAuth struct {
+	// The base64 encoding of username:password.
+	Auth          string `json:"auth,omitempty"`
+	IdentityToken string `json:"identitytoken,omitempty"`
+	Password      string `json:"password,omitempty"`
+	Username      string `json:"username,omitempty"`
+}
+
+// GetConfigFilePath returns the path of the docker config file, which is in the directory $DOCKER_CONFIG or ~/.docker.
+func GetConfigFilePath() (string, error) {
+	dir := os.Getenv("DOCKER_CONFIG")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".docker")
+	}
+	return filepath.Join(dir, "config.json"), nil
+}
+
+// LoadConfigFile reads a docker config file. An empty ConfigFile is returned if the file does not exist.
+func LoadConfigFile(file string) (*ConfigFile, error) {
+	configFile := &ConfigFile{}
+	fd, err := fs.OS.Open(file)
+	if os.IsNotExist(err) {
+		return configFile, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseAndLogError(fd)
+	err = json.NewDecoder(fd).Decode(configFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "error reading docker config file %#v", file)
+	}
+	return configFile, nil
+}
+
+// LoadDefaultConfigFile reads the docker config file at GetConfigFilePath.
+func LoadDefaultConfigFile() (*ConfigFile, error) {
+	file, err := GetConfigFilePath()
+	if err != nil {
+		return nil, err
+	}
+	return LoadConfigFile(file)
+}
+
+// NormalizeRegistryHost returns the host of a registry like the keys of the auths of a docker config file, which may be URLs.
+func NormalizeRegistryHost(host string) string {
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+	switch host {
+	case DefaultDomain, "index.docker.io", "registry-1.docker.io":
+		return "index.docker.io"
+	}
+	return host
+}
+
+func (a *ConfigAuth) toAuthConfig(key string) (*dockerTypes.AuthConfig, error) {
+	authConfig := &dockerTypes.AuthConfig{
+		IdentityToken: a.IdentityToken,
+		Password:      a.Password,
+		ServerAddress: key,
+		Username:      a.Username,
+	}
+	if a.Auth != "" {
+		decoded, err := base64.StdEncoding.DecodeString(a.Auth)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid auth of registry %s in docker config file", key)
+		}
+		parts := strings.SplitN(string(decoded), ":", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid auth of registry %s in docker config file", key)
+		}
+		authConfig.Username = parts[0]
+		authConfig.Password = parts[1]
+	}
+	return authConfig, nil
+}
+
+// getCredentialHelperAuthConfig returns the credentials of a registry as stored by a credential helper, or nil if the credential helper
+// does not have credentials of the registry.
+func getCredentialHelperAuthConfig(helper, serverURL string) (*dockerTypes.AuthConfig, error) {
+	output, err := runCredentialHelper(helper, serverURL)
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && strings.Contains(string(output)+string(exitErr.Stderr), credentialHelperNotFound) {
+			return nil, nil
+		}
+		return nil, errors.Wrapf(err, "error running credential helper docker-credential-%s for registry %s", helper, serverURL)
+	}
+	if strings.TrimSpace(string(output)) == credentialHelperNotFound {
+		return nil, nil
+	}
+	var creds struct {
+		Secret   string
+		Username string
+	}
+	err = json.Unmarshal(output, &creds)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid output of credential helper docker-credential-%s for registry %s", helper, serverURL)
+	}
+	authConfig := &dockerTypes.AuthConfig{
+		ServerAddress: serverURL,
+	}
+	if creds.Username == credentialHelperTokenUsername {
+		authConfig.IdentityToken = creds.Secret
+	} else {
+		authConfig.Username = creds.Username
+		authConfig.Password = creds.Secret
+	}
+	return authConfig, nil
+}
+
+// getCredentialHelper returns the credential helper of a registry host, or the empty string if the registry does not have one.
+func (c *ConfigFile) getCredentialHelper(normalizedHost string) string {
+	for key, helper := range c.CredHelpers {
+		if NormalizeRegistryHost(key) == normalizedHost {
+			return helper
+		}
+	}
+	return c.CredsStore
+}
+
+// GetAuthConfig returns the credentials of a registry host, or an empty AuthConfig if the docker config file has no credentials for the
+// host. Like the docker CLI, credential helpers take precedence over the auths of the docker config file.
+func (c *ConfigFile) GetAuthConfig(host string) (*dockerTypes.AuthConfig, error) {
+	normalizedHost := NormalizeRegistryHost(host)
+	if helper := c.getCredentialHelper(normalizedHost); helper != "" {
+		serverURL := normalizedHost
+		if normalizedHost == NormalizeRegistryHost(DefaultDomain) {
+			serverURL = DockerHubConfigKey
+		}
+		authConfig, err := getCredentialHelperAuthConfig(helper, serverURL)
+		if err != nil || authConfig != nil {
+			return authConfig, err
+		}
+	}
+	for key, a := range c.Auths {
+		if a != nil && NormalizeRegistryHost(key) == normalizedHost {
+			return a.toAuthConfig(key)
+		}
+	}
+	return &dockerTypes.AuthConfig{}, nil
+}
+
+// NewConfigFile returns a docker config file with the credentials of registries, as used by Kubernetes Secrets of type
+// kubernetes.io/dockerconfigjson. The keys of the map are the keys of the auths of the docker config file.
+func NewConfigFile(authConfigs map[string]*dockerTypes.AuthConfig) *ConfigFile {
+	configFile := &ConfigFile{
+		Auths: map[string]*ConfigAuth{},
+	}
+	for key, authConfig := range authConfigs {
+		configFile.Auths[key] = &ConfigAuth{
+			Auth:     base64.StdEncoding.EncodeToString([]byte(authConfig.Username + ":" + authConfig.Password)),
+			Password: authConfig.Password,
+			Username: authConfig.Username,
+		}
+	}
+	return configFile
+}
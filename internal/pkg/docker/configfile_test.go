@@ -0,0 +1,214 @@
+package docker
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/kube-compose/kube-compose/internal/pkg/fs"
+)
+
+func withMockFS(vfs fs.VirtualFileSystem, cb func()) {
+	orig := fs.OS
+	defer func() {
+		fs.OS = orig
+	}()
+	fs.OS = vfs
+	cb()
+}
+
+func TestGetConfigFilePath_DockerConfigEnv(t *testing.T) {
+	orig, ok := os.LookupEnv("DOCKER_CONFIG")
+	defer func() {
+		if ok {
+			_ = os.Setenv("DOCKER_CONFIG", orig)
+		} else {
+			_ = os.Unsetenv("DOCKER_CONFIG")
+		}
+	}()
+	_ = os.Setenv("DOCKER_CONFIG", "/dockerconfig")
+	file, err := GetConfigFilePath()
+	if err != nil || file != "/dockerconfig/config.json" {
+		t.Error(file, err)
+	}
+}
+
+func TestLoadConfigFile_NotExists(t *testing.T) {
+	withMockFS(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{}), func() {
+		configFile, err := LoadConfigFile("/config.json")
+		if err != nil || configFile == nil || len(configFile.Auths) > 0 {
+			t.Error(configFile, err)
+		}
+	})
+}
+
+func TestLoadConfigFile_Invalid(t *testing.T) {
+	withMockFS(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/config.json": {
+			Content: []byte(`{`),
+		},
+	}), func() {
+		_, err := LoadConfigFile("/config.json")
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestConfigFileGetAuthConfig_Success(t *testing.T) {
+	withMockFS(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		"/config.json": {
+			// dXNlcjE6cGFzc3dvcmQx is the base64 encoding of user1:password1.
+			Content: []byte(`{"auths":{"https://index.docker.io/v1/":{"auth":"dXNlcjE6cGFzc3dvcmQx"},` +
+				`"registry.example.com":{"identitytoken":"token1"}}}`),
+		},
+	}), func() {
+		configFile, err := LoadConfigFile("/config.json")
+		if err != nil {
+			t.Fatal(err)
+		}
+		authConfig, err := configFile.GetAuthConfig("docker.io")
+		if err != nil || authConfig.Username != "user1" || authConfig.Password != "password1" ||
+			authConfig.ServerAddress != DockerHubConfigKey {
+			t.Error(authConfig, err)
+		}
+		authConfig, err = configFile.GetAuthConfig("registry.example.com")
+		if err != nil || authConfig.IdentityToken != "token1" {
+			t.Error(authConfig, err)
+		}
+	})
+}
+
+func TestConfigFileGetAuthConfig_NoCredentials(t *testing.T) {
+	configFile := &ConfigFile{}
+	authConfig, err := configFile.GetAuthConfig("registry.example.com")
+	if err != nil || authConfig.Username != "" || authConfig.Password != "" {
+		t.Error(authConfig, err)
+	}
+}
+
+func TestConfigFileGetAuthConfig_InvalidAuth(t *testing.T) {
+	configFile := &ConfigFile{
+		Auths: map[string]*ConfigAuth{
+			"registry.example.com": {
+				// The base64 encoding of a string without a colon.
+				Auth: "dXNlcjE=",
+			},
+		},
+	}
+	_, err := configFile.GetAuthConfig("registry.example.com")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestNormalizeRegistryHost(t *testing.T) {
+	testCases := map[string]string{
+		"https://index.docker.io/v1/":     "index.docker.io",
+		"docker.io":                       "index.docker.io",
+		"registry.example.com:5000":       "registry.example.com:5000",
+		"http://registry.example.com/abc": "registry.example.com",
+	}
+	for host, expected := range testCases {
+		if actual := NormalizeRegistryHost(host); actual != expected {
+			t.Errorf("%s: %s", host, actual)
+		}
+	}
+}
+
+func withMockCredentialHelper(mock func(helper, serverURL string) ([]byte, error), cb func()) {
+	orig := runCredentialHelper
+	defer func() {
+		runCredentialHelper = orig
+	}()
+	runCredentialHelper = mock
+	cb()
+}
+
+func TestConfigFileGetAuthConfig_CredHelpers(t *testing.T) {
+	configFile := &ConfigFile{
+		Auths: map[string]*ConfigAuth{
+			"registry.example.com": {},
+		},
+		CredHelpers: map[string]string{
+			"registry.example.com": "helper1",
+		},
+		CredsStore: "store1",
+	}
+	withMockCredentialHelper(func(helper, serverURL string) ([]byte, error) {
+		if helper != "helper1" || serverURL != "registry.example.com" {
+			t.Error(helper, serverURL)
+		}
+		return []byte(`{"ServerURL":"registry.example.com","Username":"user1","Secret":"password1"}`), nil
+	}, func() {
+		authConfig, err := configFile.GetAuthConfig("registry.example.com")
+		if err != nil || authConfig.Username != "user1" || authConfig.Password != "password1" {
+			t.Error(authConfig, err)
+		}
+	})
+}
+
+func TestConfigFileGetAuthConfig_CredsStoreIdentityToken(t *testing.T) {
+	configFile := &ConfigFile{
+		CredsStore: "store1",
+	}
+	withMockCredentialHelper(func(helper, serverURL string) ([]byte, error) {
+		if helper != "store1" || serverURL != DockerHubConfigKey {
+			t.Error(helper, serverURL)
+		}
+		return []byte(`{"Username":"<token>","Secret":"token1"}`), nil
+	}, func() {
+		authConfig, err := configFile.GetAuthConfig("docker.io")
+		if err != nil || authConfig.IdentityToken != "token1" || authConfig.Username != "" {
+			t.Error(authConfig, err)
+		}
+	})
+}
+
+func TestConfigFileGetAuthConfig_CredsStoreNotFound(t *testing.T) {
+	configFile := &ConfigFile{
+		Auths: map[string]*ConfigAuth{
+			"registry.example.com": {
+				Username: "user2",
+				Password: "password2",
+			},
+		},
+		CredsStore: "store1",
+	}
+	withMockCredentialHelper(func(helper, serverURL string) ([]byte, error) {
+		return []byte(credentialHelperNotFound + "\n"), nil
+	}, func() {
+		authConfig, err := configFile.GetAuthConfig("registry.example.com")
+		if err != nil || authConfig.Username != "user2" || authConfig.Password != "password2" {
+			t.Error(authConfig, err)
+		}
+	})
+}
+
+func TestConfigFileGetAuthConfig_CredsStoreError(t *testing.T) {
+	configFile := &ConfigFile{
+		CredsStore: "store1",
+	}
+	withMockCredentialHelper(func(helper, serverURL string) ([]byte, error) {
+		return nil, fmt.Errorf("executable file not found")
+	}, func() {
+		_, err := configFile.GetAuthConfig("registry.example.com")
+		if err == nil {
+			t.Fail()
+		}
+	})
+}
+
+func TestNewConfigFile(t *testing.T) {
+	configFile := NewConfigFile(map[string]*dockerTypes.AuthConfig{
+		"registry.example.com": {
+			Username: "user1",
+			Password: "password1",
+		},
+	})
+	a := configFile.Auths["registry.example.com"]
+	if a == nil || a.Auth != "dXNlcjE6cGFzc3dvcmQx" || a.Username != "user1" || a.Password != "password1" {
+		t.Error(a)
+	}
+}
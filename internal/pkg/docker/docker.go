@@ -18,11 +18,15 @@ const (
 )
 
 func EncodeRegistryAuth(username, password string) string {
-	authConfig := dockerTypes.AuthConfig{
+	return EncodeAuthConfig(&dockerTypes.AuthConfig{
 		Username: username,
 		Password: password,
-	}
-	authConfigBytes, _ := json.Marshal(&authConfig)
+	})
+}
+
+// EncodeAuthConfig encodes credentials as the registry auth of the docker daemon API.
+func EncodeAuthConfig(authConfig *dockerTypes.AuthConfig) string {
+	authConfigBytes, _ := json.Marshal(authConfig)
 	return base64.StdEncoding.EncodeToString(authConfigBytes)
 }
 
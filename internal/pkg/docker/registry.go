@@ -0,0 +1,472 @@
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	neturl "net/url"
+	"strings"
+
+	"github.com/kube-compose/kube-compose/internal/pkg/util"
+)
+
+// ManifestMediaTypes are the media types of manifests accepted when resolving tags, so that registries return the digest of the manifest
+// that was pushed.
+var ManifestMediaTypes = []string{
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.oci.image.index.v1+json",
+}
+
+// The platform of images that is selected from manifest lists, which should match the nodes of the cluster.
+const (
+	DefaultPlatformArchitecture = "amd64"
+	DefaultPlatformOS           = "linux"
+)
+
+// dockerHubRegistryURL is the URL of the registry API of Docker Hub, which differs from the host of Docker Hub image references.
+const dockerHubRegistryURL = "https://registry-1.docker.io"
+
+// ErrorDeleteNotPermitted is returned by Registry if the registry does not permit deleting manifests, either because deletion is disabled
+// or because of insufficient privileges.
+type ErrorDeleteNotPermitted struct {
+	Repository string
+	StatusCode int
+}
+
+func (err *ErrorDeleteNotPermitted) Error() string {
+	return fmt.Sprintf("the registry does not permit deleting manifests of repository %s (status code %d)", err.Repository, err.StatusCode)
+}
+
+// Registry is a client of the Docker Registry HTTP API V2.
+type Registry struct {
+	Client *http.Client
+	// The base URL of the registry, for example https://my-docker-registry.example.com.
+	URL string
+	// If true and the registry does not support HTTPS, requests are retried over plain HTTP.
+	Insecure bool
+	// If not empty, requests are authenticated using basic authentication.
+	Username string
+	Password string
+	// If not empty, bearer tokens are obtained with this OAuth 2.0 refresh token instead of the username and password. Docker stores an
+	// identity token instead of a password in the docker config file when logging in to some registries.
+	IdentityToken string
+}
+
+// Manifest is the subset of an image manifest or a manifest list (image index) that is needed to find the configuration of an image.
+type Manifest struct {
+	MediaType string `json:"mediaType"`
+	// Only set if the manifest is an image manifest.
+	Config struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	// Only set if the manifest is a manifest list.
+	Manifests []struct {
+		Digest   string `json:"digest"`
+		Platform struct {
+			Architecture string `json:"architecture"`
+			OS           string `json:"os"`
+		} `json:"platform"`
+	} `json:"manifests"`
+}
+
+// RemoteImage is an image in a registry, as returned by Registry.GetImage.
+type RemoteImage struct {
+	// The digest of the manifest (or manifest list) referenced by the tag or digest that was resolved.
+	Digest string
+	// The digest of the configuration, which is the image ID used by the docker daemon.
+	ID string
+	// The configuration of the image. The "config" field of the configuration has the same structure as the "Config" field of the image
+	// inspect API of the docker daemon.
+	Config []byte
+}
+
+// GetRegistryURL returns the base URL of the registry API of a registry host, as in image references.
+func GetRegistryURL(host string) string {
+	if NormalizeRegistryHost(host) == NormalizeRegistryHost(DefaultDomain) {
+		return dockerHubRegistryURL
+	}
+	return "https://" + host
+}
+
+// NewHTTPClient returns a client for requests to a registry. If insecure is true then the certificate of the registry is not verified, and
+// if caFile is not empty then the certificate of the registry is verified using the CA certificates in the PEM encoded file.
+func NewHTTPClient(insecure bool, caFile string) (*http.Client, error) {
+	tlsConfig := &tls.Config{
+		// This is intended, since users opt in to insecure registries.
+		// nolint
+		InsecureSkipVerify: insecure,
+	}
+	if caFile != "" {
+		data, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("file %#v does not contain PEM encoded certificates", caFile)
+		}
+	}
+	return &http.Client{
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}, nil
+}
+
+func (r *Registry) do(ctx context.Context, method, path string, accept []string) (*http.Response, error) {
+	baseURL := strings.TrimSuffix(r.URL, "/")
+	resp, err := r.doURL(ctx, method, baseURL+path, accept)
+	if err != nil && r.Insecure && strings.HasPrefix(baseURL, "https://") &&
+		strings.Contains(err.Error(), "server gave HTTP response to HTTPS client") {
+		return r.doURL(ctx, method, "http://"+strings.TrimPrefix(baseURL, "https://")+path, accept)
+	}
+	return resp, err
+}
+
+// doURL sends a request. If the registry responds with a bearer token challenge (as most registries do), a token is obtained from the
+// authorization server and the request is sent again.
+func (r *Registry) doURL(ctx context.Context, method, url string, accept []string) (*http.Response, error) {
+	resp, err := r.send(ctx, method, url, accept, "")
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	scheme, params := ParseChallenge(resp.Header.Get("WWW-Authenticate"))
+	if !strings.EqualFold(scheme, "bearer") || params["realm"] == "" {
+		return resp, nil
+	}
+	util.CloseAndLogError(resp.Body)
+	token, err := r.getBearerToken(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	return r.send(ctx, method, url, accept, token)
+}
+
+func (r *Registry) getClient() *http.Client {
+	if r.Client == nil {
+		return http.DefaultClient
+	}
+	return r.Client
+}
+
+func (r *Registry) send(ctx context.Context, method, url string, accept []string, token string) (*http.Response, error) {
+	req, err := http.NewRequest(method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if len(accept) > 0 {
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if r.IdentityToken == "" && (r.Username != "" || r.Password != "") {
+		req.SetBasicAuth(r.Username, r.Password)
+	}
+	return r.getClient().Do(req)
+}
+
+// newTokenRequest returns a request for a token of a bearer token challenge. If the registry has an identity token then the token is
+// requested using the OAuth 2.0 refresh token grant, see https://docs.docker.com/registry/spec/auth/oauth/.
+func (r *Registry) newTokenRequest(params map[string]string) (*http.Request, error) {
+	if r.IdentityToken != "" {
+		form := neturl.Values{}
+		form.Set("grant_type", "refresh_token")
+		form.Set("refresh_token", r.IdentityToken)
+		form.Set("client_id", "kube-compose")
+		for _, key := range []string{"service", "scope"} {
+			if value := params[key]; value != "" {
+				form.Set(key, value)
+			}
+		}
+		req, err := http.NewRequest(http.MethodPost, params["realm"], strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req, nil
+	}
+	u, err := neturl.Parse(params["realm"])
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	for _, key := range []string{"service", "scope"} {
+		if value := params[key]; value != "" {
+			q.Set(key, value)
+		}
+	}
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if r.Username != "" || r.Password != "" {
+		req.SetBasicAuth(r.Username, r.Password)
+	}
+	return req, nil
+}
+
+// getBearerToken obtains a token from the authorization server of a bearer token challenge, see
+// https://docs.docker.com/registry/spec/auth/token/.
+func (r *Registry) getBearerToken(ctx context.Context, params map[string]string) (string, error) {
+	req, err := r.newTokenRequest(params)
+	if err != nil {
+		return "", err
+	}
+	resp, err := r.getClient().Do(req.WithContext(ctx))
+	if err != nil {
+		return "", err
+	}
+	defer util.CloseAndLogError(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("could not get a token from %s: unexpected status code %d", params["realm"], resp.StatusCode)
+	}
+	var body struct {
+		AccessToken string `json:"access_token"`
+		Token       string `json:"token"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&body)
+	if err != nil {
+		return "", err
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// ParseChallenge parses the value of a WWW-Authenticate header into an authentication scheme and its parameters, for example
+// Bearer realm="https://auth.docker.io/token",service="registry.docker.io".
+func ParseChallenge(header string) (string, map[string]string) {
+	header = strings.TrimSpace(header)
+	params := map[string]string{}
+	i := strings.IndexByte(header, ' ')
+	if i < 0 {
+		return header, params
+	}
+	scheme := header[:i]
+	rest := header[i+1:]
+	for {
+		rest = strings.TrimLeft(rest, " ,")
+		j := strings.IndexByte(rest, '=')
+		if j < 0 {
+			return scheme, params
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:j]))
+		rest = rest[j+1:]
+		var value strings.Builder
+		if strings.HasPrefix(rest, "\"") {
+			// Quoted values may contain commas, e.g. scope="repository:a:pull,push".
+			k := 1
+			for ; k < len(rest) && rest[k] != '"'; k++ {
+				if rest[k] == '\\' && k+1 < len(rest) {
+					k++
+				}
+				value.WriteByte(rest[k])
+			}
+			if k < len(rest) {
+				// Skip the closing quote.
+				k++
+			}
+			rest = rest[k:]
+		} else {
+			k := strings.IndexByte(rest, ',')
+			if k < 0 {
+				k = len(rest)
+			}
+			value.WriteString(strings.TrimSpace(rest[:k]))
+			rest = rest[k:]
+		}
+		params[key] = value.String()
+	}
+}
+
+func unexpectedStatusCode(method, path string, resp *http.Response) error {
+	return fmt.Errorf("unexpected status code %d for %s %s", resp.StatusCode, method, path)
+}
+
+// GetManifestDigest returns the digest of the manifest referenced by a tag, or the empty string if the manifest does not exist.
+func (r *Registry) GetManifestDigest(ctx context.Context, repository, tag string) (string, error) {
+	path := fmt.Sprintf("/v2/%s/manifests/%s", repository, tag)
+	resp, err := r.do(ctx, http.MethodHead, path, ManifestMediaTypes)
+	if err != nil {
+		return "", err
+	}
+	defer util.CloseAndLogError(resp.Body)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		digest := resp.Header.Get("Docker-Content-Digest")
+		if digest == "" {
+			return "", fmt.Errorf("the registry did not return the digest of manifest %s:%s", repository, tag)
+		}
+		return digest, nil
+	case http.StatusNotFound:
+		return "", nil
+	}
+	return "", unexpectedStatusCode(http.MethodHead, path, resp)
+}
+
+// ListTags returns the tags of a repository, or nil if the repository does not exist. Pagination is not supported.
+func (r *Registry) ListTags(ctx context.Context, repository string) ([]string, error) {
+	path := fmt.Sprintf("/v2/%s/tags/list", repository)
+	resp, err := r.do(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer util.CloseAndLogError(resp.Body)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var body struct {
+			Tags []string `json:"tags"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		if err != nil {
+			return nil, err
+		}
+		return body.Tags, nil
+	case http.StatusNotFound:
+		return nil, nil
+	}
+	return nil, unexpectedStatusCode(http.MethodGet, path, resp)
+}
+
+// DeleteManifest deletes a manifest by digest. It is not an error if the manifest does not exist.
+func (r *Registry) DeleteManifest(ctx context.Context, repository, digest string) error {
+	path := fmt.Sprintf("/v2/%s/manifests/%s", repository, digest)
+	resp, err := r.do(ctx, http.MethodDelete, path, nil)
+	if err != nil {
+		return err
+	}
+	defer util.CloseAndLogError(resp.Body)
+	switch resp.StatusCode {
+	case http.StatusAccepted, http.StatusOK, http.StatusNotFound:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusMethodNotAllowed:
+		return &ErrorDeleteNotPermitted{
+			Repository: repository,
+			StatusCode: resp.StatusCode,
+		}
+	}
+	return unexpectedStatusCode(http.MethodDelete, path, resp)
+}
+
+// DeleteTag deletes the manifest referenced by a tag. The registry API can only delete manifests, which deletes all tags of the manifest,
+// so the manifest is not deleted if another tag of the repository references it. Returns true if the manifest was deleted.
+func (r *Registry) DeleteTag(ctx context.Context, repository, tag string) (bool, error) {
+	digest, err := r.GetManifestDigest(ctx, repository, tag)
+	if err != nil || digest == "" {
+		return false, err
+	}
+	tags, err := r.ListTags(ctx, repository)
+	if err != nil {
+		return false, err
+	}
+	for _, otherTag := range tags {
+		if otherTag == tag {
+			continue
+		}
+		otherDigest, err := r.GetManifestDigest(ctx, repository, otherTag)
+		if err != nil {
+			return false, err
+		}
+		if otherDigest == digest {
+			return false, nil
+		}
+	}
+	err = r.DeleteManifest(ctx, repository, digest)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// getVerified gets a manifest or blob by path and verifies that the content matches the digest, if the digest is a sha256 digest. The digest
+// of the content is returned, which is the sha256 digest if the digest is empty.
+func (r *Registry) getVerified(ctx context.Context, path string, accept []string, expectedDigest string) ([]byte, string, error) {
+	resp, err := r.do(ctx, http.MethodGet, path, accept)
+	if err != nil {
+		return nil, "", err
+	}
+	defer util.CloseAndLogError(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", unexpectedStatusCode(http.MethodGet, path, resp)
+	}
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	digest := fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+	if strings.HasPrefix(expectedDigest, "sha256:") && expectedDigest != digest {
+		return nil, "", fmt.Errorf("the content of %s does not match digest %s", path, expectedDigest)
+	}
+	if expectedDigest != "" {
+		return data, expectedDigest, nil
+	}
+	return data, digest, nil
+}
+
+// GetManifest returns a manifest by tag or digest, and the digest of the manifest.
+func (r *Registry) GetManifest(ctx context.Context, repository, reference string) (*Manifest, string, error) {
+	// Tags cannot contain colons, so the reference is a digest if it contains a colon.
+	var expectedDigest string
+	if strings.Contains(reference, ":") {
+		expectedDigest = reference
+	}
+	data, digest, err := r.getVerified(ctx, fmt.Sprintf("/v2/%s/manifests/%s", repository, reference), ManifestMediaTypes, expectedDigest)
+	if err != nil {
+		return nil, "", err
+	}
+	manifest := &Manifest{}
+	err = json.Unmarshal(data, manifest)
+	if err != nil {
+		return nil, "", err
+	}
+	return manifest, digest, nil
+}
+
+// GetImage returns the image referenced by a tag or digest. If the reference is a manifest list then the image of the platform
+// DefaultPlatformOS/DefaultPlatformArchitecture is returned.
+func (r *Registry) GetImage(ctx context.Context, repository, reference string) (*RemoteImage, error) {
+	manifest, digest, err := r.GetManifest(ctx, repository, reference)
+	if err != nil {
+		return nil, err
+	}
+	image := &RemoteImage{
+		Digest: digest,
+	}
+	if len(manifest.Manifests) > 0 {
+		platformDigest := ""
+		for _, m := range manifest.Manifests {
+			if m.Platform.OS == DefaultPlatformOS && m.Platform.Architecture == DefaultPlatformArchitecture {
+				platformDigest = m.Digest
+				break
+			}
+		}
+		if platformDigest == "" {
+			return nil, fmt.Errorf("image %s:%s does not have a manifest for platform %s/%s", repository, reference, DefaultPlatformOS,
+				DefaultPlatformArchitecture)
+		}
+		manifest, _, err = r.GetManifest(ctx, repository, platformDigest)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if manifest.Config.Digest == "" {
+		return nil, fmt.Errorf("manifest %s:%s of media type %#v is not supported", repository, reference, manifest.MediaType)
+	}
+	image.ID = manifest.Config.Digest
+	image.Config, _, err = r.getVerified(ctx, fmt.Sprintf("/v2/%s/blobs/%s", repository, image.ID), nil, image.ID)
+	if err != nil {
+		return nil, err
+	}
+	return image, nil
+}
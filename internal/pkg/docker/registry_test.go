@@ -0,0 +1,289 @@
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// newTestRegistryServer returns a server that serves the manifests of a repository, where manifests are identified by digest.
+func newTestRegistryServer(t *testing.T, tags map[string]string, deleteStatusCode int, deleted *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, password, ok := r.BasicAuth(); !ok || user != "unused" || password != "token1" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/v2/ns/a/tags/list":
+			_, _ = w.Write([]byte(`{"name":"ns/a","tags":["env1-main","env2-main","env1-volumeinit"]}`))
+		case r.Method == http.MethodHead:
+			for tag, digest := range tags {
+				if r.URL.Path == "/v2/ns/a/manifests/"+tag {
+					w.Header().Set("Docker-Content-Digest", digest)
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodDelete:
+			*deleted = append(*deleted, r.URL.Path)
+			w.WriteHeader(deleteStatusCode)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+}
+
+func newTestRegistry(server *httptest.Server) *Registry {
+	return &Registry{
+		URL:      server.URL,
+		Username: "unused",
+		Password: "token1",
+	}
+}
+
+func TestRegistryDeleteTag_Success(t *testing.T) {
+	var deleted []string
+	server := newTestRegistryServer(t, map[string]string{
+		"env1-main":       "sha256:1",
+		"env2-main":       "sha256:2",
+		"env1-volumeinit": "sha256:3",
+	}, http.StatusAccepted, &deleted)
+	defer server.Close()
+	ok, err := newTestRegistry(server).DeleteTag(context.Background(), "ns/a", "env1-main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok || len(deleted) != 1 || deleted[0] != "/v2/ns/a/manifests/sha256:1" {
+		t.Error(ok, deleted)
+	}
+}
+
+func TestRegistryDeleteTag_SharedManifest(t *testing.T) {
+	var deleted []string
+	server := newTestRegistryServer(t, map[string]string{
+		"env1-main": "sha256:1",
+		"env2-main": "sha256:1",
+	}, http.StatusAccepted, &deleted)
+	defer server.Close()
+	ok, err := newTestRegistry(server).DeleteTag(context.Background(), "ns/a", "env1-main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok || len(deleted) != 0 {
+		t.Error(ok, deleted)
+	}
+}
+
+func TestRegistryDeleteTag_NotFound(t *testing.T) {
+	var deleted []string
+	server := newTestRegistryServer(t, map[string]string{}, http.StatusAccepted, &deleted)
+	defer server.Close()
+	ok, err := newTestRegistry(server).DeleteTag(context.Background(), "ns/a", "env1-main")
+	if err != nil || ok {
+		t.Error(ok, err)
+	}
+}
+
+func TestRegistryDeleteTag_NotPermitted(t *testing.T) {
+	var deleted []string
+	server := newTestRegistryServer(t, map[string]string{
+		"env1-main": "sha256:1",
+	}, http.StatusMethodNotAllowed, &deleted)
+	defer server.Close()
+	_, err := newTestRegistry(server).DeleteTag(context.Background(), "ns/a", "env1-main")
+	if errNotPermitted, ok := err.(*ErrorDeleteNotPermitted); !ok || errNotPermitted.StatusCode != http.StatusMethodNotAllowed {
+		t.Error(err)
+	}
+}
+
+func TestRegistryGetManifestDigest_Unauthorized(t *testing.T) {
+	var deleted []string
+	server := newTestRegistryServer(t, map[string]string{}, http.StatusAccepted, &deleted)
+	defer server.Close()
+	registry := newTestRegistry(server)
+	registry.Password = "token2"
+	_, err := registry.GetManifestDigest(context.Background(), "ns/a", "env1-main")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := ParseChallenge(`Bearer realm="https://auth.example.com/token",service="registry.example.com",` +
+		`scope="repository:ns/a:pull,push"`)
+	expected := map[string]string{
+		"realm":   "https://auth.example.com/token",
+		"service": "registry.example.com",
+		"scope":   "repository:ns/a:pull,push",
+	}
+	if scheme != "Bearer" || !reflect.DeepEqual(params, expected) {
+		t.Error(scheme, params)
+	}
+}
+
+func TestParseChallenge_NoParams(t *testing.T) {
+	scheme, params := ParseChallenge("Basic")
+	if scheme != "Basic" || len(params) > 0 {
+		t.Error(scheme, params)
+	}
+}
+
+func TestRegistryGetManifestDigest_BearerToken(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if user, password, ok := r.BasicAuth(); !ok || user != "user1" || password != "password1" ||
+				r.URL.Query().Get("scope") != "repository:ns/a:pull" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token":"token1"}`))
+		case "/v2/ns/a/manifests/env1-main":
+			if r.Header.Get("Authorization") != "Bearer token1" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",scope="repository:ns/a:pull"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", "sha256:1")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	registry := &Registry{
+		URL:      server.URL,
+		Username: "user1",
+		Password: "password1",
+	}
+	digest, err := registry.GetManifestDigest(context.Background(), "ns/a", "env1-main")
+	if err != nil || digest != "sha256:1" {
+		t.Error(digest, err)
+	}
+}
+
+func TestRegistryGetManifestDigest_IdentityToken(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if r.Method != http.MethodPost || r.PostFormValue("grant_type") != "refresh_token" ||
+				r.PostFormValue("refresh_token") != "identitytoken1" || r.PostFormValue("scope") != "repository:ns/a:pull" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"access_token":"token1"}`))
+		case "/v2/ns/a/manifests/env1-main":
+			if r.Header.Get("Authorization") != "Bearer token1" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",scope="repository:ns/a:pull"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", "sha256:1")
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	registry := &Registry{
+		URL:           server.URL,
+		Username:      "<token>",
+		IdentityToken: "identitytoken1",
+	}
+	digest, err := registry.GetManifestDigest(context.Background(), "ns/a", "env1-main")
+	if err != nil || digest != "sha256:1" {
+		t.Error(digest, err)
+	}
+}
+
+func TestNewHTTPClient_CAFileNotExists(t *testing.T) {
+	_, err := NewHTTPClient(false, "/does/not/exist.pem")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+const testImageConfig = `{"config":{"Cmd":["sh"],"User":"1000"}}`
+
+// newTestImageRegistryServer returns a server that serves a manifest list with tag latest of repository ns/a.
+func newTestImageRegistryServer() (*httptest.Server, string) {
+	configDigest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(testImageConfig)))
+	manifest := `{"mediaType":"application/vnd.docker.distribution.manifest.v2+json","config":{"digest":"` + configDigest + `"}}`
+	manifestDigest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(manifest)))
+	manifestList := `{"mediaType":"application/vnd.docker.distribution.manifest.list.v2+json","manifests":[` +
+		`{"digest":"sha256:0","platform":{"architecture":"arm64","os":"linux"}},` +
+		`{"digest":"` + manifestDigest + `","platform":{"architecture":"amd64","os":"linux"}}]}`
+	manifestListDigest := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(manifestList)))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/ns/a/manifests/latest", "/v2/ns/a/manifests/" + manifestListDigest, "/v2/ns/a/manifests/sha256:0000":
+			_, _ = w.Write([]byte(manifestList))
+		case "/v2/ns/a/manifests/" + manifestDigest:
+			_, _ = w.Write([]byte(manifest))
+		case "/v2/ns/a/blobs/" + configDigest:
+			_, _ = w.Write([]byte(testImageConfig))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server, manifestListDigest
+}
+
+func TestRegistryGetImage_ManifestList(t *testing.T) {
+	server, manifestListDigest := newTestImageRegistryServer()
+	defer server.Close()
+	registry := &Registry{
+		URL: server.URL,
+	}
+	image, err := registry.GetImage(context.Background(), "ns/a", "latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if image.Digest != manifestListDigest || string(image.Config) != testImageConfig ||
+		image.ID != fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(testImageConfig))) {
+		t.Error(image)
+	}
+	image, err = registry.GetImage(context.Background(), "ns/a", manifestListDigest)
+	if err != nil || image.Digest != manifestListDigest {
+		t.Error(image, err)
+	}
+}
+
+func TestRegistryGetImage_DigestMismatch(t *testing.T) {
+	server, _ := newTestImageRegistryServer()
+	defer server.Close()
+	registry := &Registry{
+		URL: server.URL,
+	}
+	// The server returns the manifest list for this path, whose digest differs.
+	_, err := registry.GetImage(context.Background(), "ns/a", "sha256:0000")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestRegistryGetImage_NotFound(t *testing.T) {
+	server, _ := newTestImageRegistryServer()
+	defer server.Close()
+	registry := &Registry{
+		URL: server.URL,
+	}
+	_, err := registry.GetImage(context.Background(), "ns/b", "latest")
+	if err == nil {
+		t.Fail()
+	}
+}
+
+func TestGetRegistryURL(t *testing.T) {
+	if actual := GetRegistryURL("docker.io"); actual != "https://registry-1.docker.io" {
+		t.Error(actual)
+	}
+	if actual := GetRegistryURL("registry.example.com:5000"); actual != "https://registry.example.com:5000" {
+		t.Error(actual)
+	}
+}
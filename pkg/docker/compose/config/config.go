@@ -47,19 +47,26 @@ type Service struct {
 	// When adding a field here, please update merge.go with the logic required to merge these fields.
 	Command []string
 	// TODO https://github.com/kube-compose/kube-compose/issues/214 consider simplifying to map[string]ServiceHealthiness
-	DependsOn           map[string]ServiceHealthiness
-	Entrypoint          []string
-	Environment         map[string]string
+	DependsOn   map[string]ServiceHealthiness
+	Entrypoint  []string
+	Environment map[string]string
+	// The ports as set by expose. These ports are never published, so ExternalMin and ExternalMax are always -1.
+	Expose              []PortBinding
 	Healthcheck         *Healthcheck
 	HealthcheckDisabled bool
 	Image               string
 	Name                string
 	Ports               []PortBinding
 	Privileged          bool
-	Restart             string
-	User                *string
-	Volumes             []ServiceVolume
-	WorkingDir          string
+	// The number of replicas as set by deploy.replicas, or nil if not set.
+	Replicas   *uint
+	Restart    string
+	User       *string
+	Volumes    []ServiceVolume
+	WorkingDir string
+	// The x- properties of the service, for each docker compose file (or extended service) that contributed to this service.
+	// Similar to CanonicalDockerComposeConfig.XProperties, elements later in the slice take precedence over those earlier in the slice.
+	XProperties []XProperties
 }
 
 // serviceInternal is a helper struct that is a smaller piece of dockerComposeFile.
@@ -68,10 +75,13 @@ type serviceInternal struct {
 	// TODO https://github.com/kube-compose/kube-compose/issues/153 interpret string command/entrypoint correctly
 	Command   *stringOrStringSlice `mapdecode:"command"`
 	DependsOn *dependsOn           `mapdecode:"depends_on"`
+	Deploy    *deploy              `mapdecode:"deploy"`
 	// TODO https://github.com/kube-compose/kube-compose/issues/153 interpret string command/entrypoint correctly
 	Entrypoint        *stringOrStringSlice `mapdecode:"entrypoint"`
 	Environment       *environment         `mapdecode:"environment"`
 	environmentParsed map[string]string
+	Expose            []port `mapdecode:"expose"`
+	exposeParsed      []PortBinding
 	Extends           *extends `mapdecode:"extends"`
 	// The final docker compose service in CanonicalDockerComposeConfig (only set if this is not an intermediate result).
 	finalService *Service
@@ -90,6 +100,8 @@ type serviceInternal struct {
 	visited    bool
 	Volumes    []ServiceVolume `mapdecode:"volumes"`
 	WorkingDir *string         `mapdecode:"working_dir"`
+	// The x- properties of the service, ordered such that elements later in the slice take precedence.
+	xProperties []XProperties
 }
 
 // A helper for defer
@@ -217,6 +229,7 @@ func (c *configLoader) loadResolvedFileCore(resolvedFile string, dcFile *dockerC
 	if err != nil {
 		return err
 	}
+	setServiceXProperties(dcFile, dataMap)
 
 	// validation after parsing
 	return c.parseDockerComposeFile(dcFile)
@@ -459,6 +472,7 @@ func finalizeService(s *serviceInternal) error {
 		s.finalService.Entrypoint = s.Entrypoint.Values
 	}
 	s.finalService.Environment = s.environmentParsed
+	s.finalService.Expose = s.exposeParsed
 
 	// Healthchecks are processed after merging.
 	healthcheck, healthcheckDisabled, err := ParseHealthcheck(s.Healthcheck)
@@ -476,6 +490,9 @@ func finalizeService(s *serviceInternal) error {
 	if s.Privileged != nil {
 		s.finalService.Privileged = *s.Privileged
 	}
+	if s.Deploy != nil {
+		s.finalService.Replicas = s.Deploy.Replicas
+	}
 	if s.Restart != nil {
 		s.finalService.Restart = *s.Restart
 	}
@@ -484,6 +501,7 @@ func finalizeService(s *serviceInternal) error {
 	if s.WorkingDir != nil {
 		s.finalService.WorkingDir = *s.WorkingDir
 	}
+	s.finalService.XProperties = s.xProperties
 	return nil
 }
 
@@ -506,6 +524,19 @@ func getXProperties(gm interface{}) XProperties {
 	return result
 }
 
+// setServiceXProperties copies the x- properties of each service in dataMap to the corresponding service of dcFile.
+func setServiceXProperties(dcFile *dockerComposeFile, dataMap genericMap) {
+	servicesMap, ok := dataMap["services"].(genericMap)
+	if !ok {
+		return
+	}
+	for name, s := range dcFile.Services {
+		if xProperties := getXProperties(servicesMap[name]); xProperties != nil {
+			s.xProperties = []XProperties{xProperties}
+		}
+	}
+}
+
 func resolveDependsOn(services map[string]*serviceInternal) error {
 	for _, s1 := range services {
 		s1.finalService = &Service{}
@@ -575,6 +606,10 @@ func (c *configLoader) parseDockerComposeFileService(dcFile *dockerComposeFile,
 	if err != nil {
 		return err
 	}
+	s.exposeParsed, err = parseExpose(s.Expose)
+	if err != nil {
+		return err
+	}
 	if s.Environment != nil {
 		s.environmentParsed, err = c.parseEnvironment(s.Environment.Values)
 		if err != nil {
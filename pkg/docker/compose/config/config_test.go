@@ -1200,3 +1200,86 @@ func Test_LoadStandardFilesTry_LoadResolvedFileError(t *testing.T) {
 		}
 	})
 }
+
+func Test_New_ServiceXPropertiesAndReplicasMergeSuccess(t *testing.T) {
+	file1 := "/servicexproperties1.yml"
+	file2 := "/servicexproperties2.yml"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file1: {
+			Content: []byte(`version: '3'
+services:
+  service1:
+    image: ubuntu:latest
+    deploy:
+      replicas: 2
+    x-key: value1
+`),
+		},
+		file2: {
+			Content: []byte(`version: '3'
+services:
+  service1:
+    x-key: value2
+`),
+		},
+	}), func() {
+		c, err := New([]string{file1, file2})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		service1 := c.Services["service1"]
+		if service1.Replicas == nil || *service1.Replicas != 2 {
+			t.Fail()
+		}
+		expected := []XProperties{
+			{"x-key": "value1"},
+			{"x-key": "value2"},
+		}
+		if !reflect.DeepEqual(service1.XProperties, expected) {
+			t.Logf("xProperties1: %+v\n", service1.XProperties)
+			t.Logf("xProperties2: %+v\n", expected)
+			t.Fail()
+		}
+	})
+}
+
+func Test_New_ExposeMergeSuccess(t *testing.T) {
+	file1 := "/expose1.yml"
+	file2 := "/expose2.yml"
+	withMockFS2(fs.NewInMemoryUnixFileSystem(map[string]fs.InMemoryFile{
+		file1: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    image: ubuntu:latest
+    expose:
+    - 8080
+`),
+		},
+		file2: {
+			Content: []byte(`version: '2.4'
+services:
+  service1:
+    expose:
+    - 8080
+    - "53/udp"
+`),
+		},
+	}), func() {
+		c, err := New([]string{file1, file2})
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		expected := []PortBinding{
+			{Internal: 8080, ExternalMin: -1, ExternalMax: -1, Protocol: "tcp"},
+			{Internal: 53, ExternalMin: -1, ExternalMax: -1, Protocol: "udp"},
+		}
+		if !reflect.DeepEqual(c.Services["service1"].Expose, expected) {
+			t.Logf("expose1: %+v\n", c.Services["service1"].Expose)
+			t.Logf("expose2: %+v\n", expected)
+			t.Fail()
+		}
+	})
+}
@@ -28,9 +28,14 @@ func merge(into, from *serviceInternal, mergeExtends bool) {
 	into.DependsOn = mergeDependsOnMaps(into.DependsOn, from.DependsOn)
 	into.environmentParsed = mergeStringMaps(into.environmentParsed, from.environmentParsed)
 	into.Healthcheck = mergeHealthchecks(into.Healthcheck, from.Healthcheck)
+	into.exposeParsed = mergePortBindings(into.exposeParsed, from.exposeParsed)
 	into.portsParsed = mergePortBindings(into.portsParsed, from.portsParsed)
 	into.Volumes = mergeVolumes(into.Volumes, from.Volumes)
+	into.xProperties = mergeXProperties(into.xProperties, from.xProperties)
 
+	if into.Deploy == nil {
+		into.Deploy = from.Deploy
+	}
 	if into.Entrypoint == nil {
 		into.Entrypoint = from.Entrypoint
 	}
@@ -115,6 +120,7 @@ func mergeServices(into, from map[string]*serviceInternal) {
 					Values: map[string]ServiceHealthiness{},
 				},
 				environmentParsed: map[string]string{},
+				exposeParsed:      []PortBinding{},
 				Healthcheck:       &healthcheckInternal{},
 				name:              name,
 				portsParsed:       []PortBinding{},
@@ -147,3 +153,14 @@ func mergeVolumes(into, from []ServiceVolume) []ServiceVolume {
 	}
 	return into
 }
+
+// mergeXProperties merges the x- properties of two services. Since elements later in the slice take precedence, the x- properties of from
+// are prepended. A new slice is always allocated, so that from is never mutated.
+func mergeXProperties(into, from []XProperties) []XProperties {
+	if len(from) == 0 {
+		return into
+	}
+	merged := make([]XProperties, 0, len(from)+len(into))
+	merged = append(merged, from...)
+	return append(merged, into...)
+}
@@ -106,6 +106,10 @@ func (t *dependsOn) Decode(into mapdecode.Into) error {
 	return nil
 }
 
+type deploy struct {
+	Replicas *uint `mapdecode:"replicas"`
+}
+
 type environmentNameValuePair struct {
 	Name  string
 	Value *environmentValue
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 
 	"github.com/kube-compose/kube-compose/internal/pkg/util"
 	"github.com/pkg/errors"
@@ -194,3 +195,23 @@ func parsePorts(inputs []port) ([]PortBinding, error) {
 	}
 	return portBindings, nil
 }
+
+// https://docs.docker.com/compose/compose-file/compose-file-v2/#expose
+// expose:
+//  - "3000"
+//  - "8000-8010"
+//  - "53/udp"
+func parseExpose(inputs []port) ([]PortBinding, error) {
+	portBindings := []PortBinding{}
+	for _, input := range inputs {
+		if strings.Contains(input.Value, ":") {
+			return nil, fmt.Errorf("invalid expose %q, should be port[-port][/protocol]", input.Value)
+		}
+		var err error
+		portBindings, err = parsePortBindings(input.Value, portBindings)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return portBindings, nil
+}
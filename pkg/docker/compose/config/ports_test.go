@@ -182,3 +182,47 @@ func Test_ParsePorts_DuplicatePortsError(t *testing.T) {
 		t.Fail()
 	}
 }
+
+func Test_ParseExpose_Success(t *testing.T) {
+	expected := []PortBinding{
+		{
+			Internal:    3000,
+			ExternalMin: -1,
+			ExternalMax: -1,
+			Protocol:    "tcp",
+		},
+		{
+			Internal:    8000,
+			ExternalMin: -1,
+			ExternalMax: -1,
+			Protocol:    "tcp",
+		},
+		{
+			Internal:    8001,
+			ExternalMin: -1,
+			ExternalMax: -1,
+			Protocol:    "tcp",
+		},
+		{
+			Internal:    53,
+			ExternalMin: -1,
+			ExternalMax: -1,
+			Protocol:    "udp",
+		},
+	}
+	actual, err := parseExpose([]port{{Value: "3000"}, {Value: "8000-8001"}, {Value: "53/udp"}})
+	if err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(actual, expected) {
+		t.Logf("ports1: %+v\n", actual)
+		t.Logf("ports2: %+v\n", expected)
+		t.Fail()
+	}
+}
+
+func Test_ParseExpose_PublishedError(t *testing.T) {
+	_, err := parseExpose([]port{{Value: "8080:80"}})
+	if err == nil {
+		t.Fail()
+	}
+}